@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/deis/deis/pkg/prettyprint"
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/deis/deis/client-go/controller/api"
 	"github.com/deis/deis/client-go/controller/client"
@@ -52,19 +59,23 @@ func AppCreate(id string, buildpack string, remote string, noRemote bool) error
 }
 
 // AppsList lists apps on the Deis controller.
-func AppsList() error {
+func AppsList(results int) error {
 	c, err := client.New()
 
 	if err != nil {
 		return err
 	}
 
-	apps, err := apps.List(c)
+	apps, err := apps.List(c, results)
 
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(apps)
+	}
+
 	fmt.Println("=== Apps")
 
 	for _, app := range apps {
@@ -87,6 +98,10 @@ func AppInfo(appID string) error {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(app)
+	}
+
 	fmt.Printf("=== %s Application\n", app.ID)
 	fmt.Println("updated: ", app.Updated)
 	fmt.Println("uuid:    ", app.UUID)
@@ -124,39 +139,198 @@ func AppOpen(appID string) error {
 }
 
 // AppLogs returns the logs from an app.
-func AppLogs(appID string, lines int) error {
+func AppLogs(appID string, lines int, follow bool, ps string, source string, since string,
+	until string, grep string) error {
+
 	c, appID, err := load(appID)
 
 	if err != nil {
 		return err
 	}
 
-	logs, err := apps.Logs(c, appID, lines)
+	if follow {
+		return followLogs(c, appID, lines, ps, source)
+	}
+
+	logs, err := apps.Logs(c, appID, lines, ps, source, since, until, grep)
 
 	if err != nil {
 		return err
 	}
 
 	for _, log := range strings.Split(strings.Trim(logs, `\n`), `\n`) {
-		catagory := strings.Split(strings.Split(log, ": ")[0], " ")[1]
-		colorVars := map[string]string{
-			"Color": chooseColor(catagory),
-			"Log":   log,
-		}
-		fmt.Println(prettyprint.ColorizeVars("{{.V.Color}}{{.V.Log}}{{.C.Default}}", colorVars))
+		printLog(log)
 	}
 
 	return nil
 }
 
+// followOverlap is how many lines a reconnect asks for again, so that lines logged while
+// reconnecting are not lost. The ones already printed are skipped.
+const followOverlap = 100
+
+// Delays before reconnecting to follow logs, doubling from the minimum up to the maximum
+// while streams keep failing.
+var (
+	minFollowDelay = time.Second
+	maxFollowDelay = 30 * time.Second
+)
+
+// followLogs prints an app's logs as they are written, reconnecting with backoff whenever
+// the stream ends or fails.
+func followLogs(c *client.Client, appID string, lines int, ps string, source string) error {
+	resumer := &logResumer{print: printLog}
+	delay := minFollowDelay
+
+	for attempt := 0; ; attempt++ {
+		stream, err := apps.LogsFollow(c, appID, lines, ps, source)
+
+		if err != nil && attempt == 0 {
+			return err
+		}
+
+		if err == nil {
+			started := time.Now()
+			err = resumer.copy(stream)
+			stream.Close()
+
+			// a stream that ran for a while ended normally, so reconnect quickly
+			if time.Since(started) > time.Minute {
+				delay = minFollowDelay
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Reconnecting in %v: %v\n", delay, err)
+		}
+
+		time.Sleep(delay)
+
+		if delay *= 2; delay > maxFollowDelay {
+			delay = maxFollowDelay
+		}
+
+		lines = followOverlap
+		resumer.resume()
+	}
+}
+
+// logResumer prints followed lines, skipping those a reconnect sends again.
+type logResumer struct {
+	print func(string)
+	// the last lines printed, up to followOverlap of them
+	recent []string
+	// lines read since resuming that match a run of recent, held until they are known to
+	// have been printed
+	held []string
+	// how many of the held lines are known to have been printed
+	printed  int
+	resuming bool
+}
+
+// resume starts skipping the lines at the start of a new stream that were already printed.
+func (r *logResumer) resume() {
+	r.held, r.printed = nil, 0
+	r.resuming = len(r.recent) > 0
+}
+
+// copy prints the lines of stream until it ends.
+func (r *logResumer) copy(stream io.Reader) error {
+	reader := bufio.NewReader(stream)
+
+	for {
+		log, err := reader.ReadString('\n')
+
+		if log != "" {
+			r.line(strings.TrimSuffix(log, "\n"))
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *logResumer) line(log string) {
+	if !r.resuming {
+		r.printLine(log)
+		return
+	}
+
+	r.held = append(r.held, log)
+
+	// the held lines end where the last stream did, so they were all printed
+	if n := len(r.recent) - len(r.held); n >= 0 && equalLines(r.recent[n:], r.held) {
+		r.printed = len(r.held)
+	}
+
+	// the held lines may still run further towards where the last stream ended
+	for n := 0; n+len(r.held) < len(r.recent); n++ {
+		if equalLines(r.recent[n:n+len(r.held)], r.held) {
+			return
+		}
+	}
+
+	held := r.held[r.printed:]
+	r.held, r.printed, r.resuming = nil, 0, false
+
+	for _, log := range held {
+		r.printLine(log)
+	}
+}
+
+func (r *logResumer) printLine(log string) {
+	r.print(log)
+
+	if r.recent = append(r.recent, log); len(r.recent) > followOverlap {
+		r.recent = r.recent[len(r.recent)-followOverlap:]
+	}
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func printLog(log string) {
+	var category string
+
+	if parts := strings.Split(strings.Split(log, ": ")[0], " "); len(parts) > 1 {
+		category = parts[1]
+	}
+
+	colorVars := map[string]string{
+		"Color": chooseColor(category),
+		"Log":   log,
+	}
+	fmt.Println(prettyprint.ColorizeVars("{{.V.Color}}{{.V.Log}}{{.C.Default}}", colorVars))
+}
+
 // AppRun runs a one time command in the app.
-func AppRun(appID, command string) error {
+func AppRun(appID, command string, interactive, tty bool) error {
 	c, appID, err := load(appID)
 
 	if err != nil {
 		return err
 	}
 
+	if interactive || tty {
+		return appRunAttach(c, appID, command, tty)
+	}
+
 	fmt.Printf("Running '%s'...\n", command)
 
 	out, err := apps.Run(c, appID, command)
@@ -170,6 +344,60 @@ func AppRun(appID, command string) error {
 	return nil
 }
 
+func appRunAttach(c *client.Client, appID, command string, tty bool) error {
+	fd := int(os.Stdin.Fd())
+	req := api.AppRunAttachRequest{Command: command, TTY: tty}
+	resize := make(chan apps.WindowSize)
+	var oldState *terminal.State
+
+	if tty {
+		if !terminal.IsTerminal(fd) {
+			return errors.New("--tty requires stdin to be a terminal")
+		}
+
+		width, height, err := terminal.GetSize(fd)
+
+		if err != nil {
+			return err
+		}
+
+		req.Width, req.Height = width, height
+
+		oldState, err = terminal.MakeRaw(fd)
+
+		if err != nil {
+			return err
+		}
+		defer terminal.Restore(fd, oldState)
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGWINCH)
+		defer signal.Stop(sigs)
+
+		go func() {
+			for range sigs {
+				if width, height, err := terminal.GetSize(fd); err == nil {
+					resize <- apps.WindowSize{Width: width, Height: height}
+				}
+			}
+		}()
+	}
+
+	rc, err := apps.Attach(c, appID, req, os.Stdin, os.Stdout, os.Stderr, resize)
+
+	if err != nil {
+		return err
+	}
+
+	if tty {
+		// os.Exit skips deferred calls, so put the terminal back first.
+		terminal.Restore(fd, oldState)
+	}
+
+	os.Exit(rc)
+	return nil
+}
+
 // AppDestroy destroys an app.
 func AppDestroy(appID, confirm string) error {
 	gitSession := false
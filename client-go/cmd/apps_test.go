@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogResumer(t *testing.T) {
+	t.Parallel()
+
+	var printed []string
+	r := &logResumer{print: func(log string) { printed = append(printed, log) }}
+
+	if err := r.copy(strings.NewReader("a\nb\nc\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the reconnect sends back b and c, then the line logged in between and a new one
+	r.resume()
+	if err := r.copy(strings.NewReader("b\nc\nd\ne\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the overlap held nothing printed before
+	r.resume()
+	if err := r.copy(strings.NewReader("x\ny\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a", "b", "c", "d", "e", "x", "y"}
+
+	if !reflect.DeepEqual(printed, expected) {
+		t.Errorf("Expected %v, Got %v", expected, printed)
+	}
+}
+
+func TestLogResumerRepeatedLines(t *testing.T) {
+	t.Parallel()
+
+	var printed []string
+	r := &logResumer{print: func(log string) { printed = append(printed, log) }}
+
+	if err := r.copy(strings.NewReader("a\nb\na\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	r.resume()
+	if err := r.copy(strings.NewReader("a\nb\na\nb\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a", "b", "a", "b"}
+
+	if !reflect.DeepEqual(printed, expected) {
+		t.Errorf("Expected %v, Got %v", expected, printed)
+	}
+}
@@ -60,7 +60,8 @@ func Register(controller string, username string, password string, email string,
 }
 
 // Login to a Deis controller.
-func Login(controller string, username string, password string, sslVerify bool) error {
+func Login(controller string, username string, password string, sslVerify bool,
+	profile string) error {
 	u, err := url.Parse(controller)
 
 	if err != nil {
@@ -92,7 +93,7 @@ func Login(controller string, username string, password string, sslVerify bool)
 		}
 	}
 
-	return client.Login(controllerURL, username, password, sslVerify)
+	return client.Login(controllerURL, username, password, sslVerify, profile)
 }
 
 // Logout from a Deis controller.
@@ -144,7 +145,7 @@ func Cancel(username string, password string, yes bool) error {
 
 	fmt.Println("Please log in again in order to cancel this account")
 
-	if err = Login(c.ControllerURL.String(), username, password, c.SSLVerify); err != nil {
+	if err = Login(c.ControllerURL.String(), username, password, c.SSLVerify, c.Profile); err != nil {
 		return err
 	}
 
@@ -9,19 +9,23 @@ import (
 )
 
 // BuildsList lists an app's builds.
-func BuildsList(appID string) error {
+func BuildsList(appID string, results int) error {
 	c, appID, err := load(appID)
 
 	if err != nil {
 		return err
 	}
 
-	builds, err := builds.List(c, appID)
+	builds, err := builds.List(c, appID, results)
 
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(builds)
+	}
+
 	fmt.Printf("=== %s Builds\n", appID)
 
 	for _, build := range builds {
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/deis/deis/client-go/controller/client"
+	"github.com/deis/deis/client-go/controller/models/certs"
+)
+
+// CertsList lists certs registered with the controller.
+func CertsList(results int) error {
+	c, err := client.New()
+
+	if err != nil {
+		return err
+	}
+
+	certList, err := certs.List(c, results)
+
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(certList)
+	}
+
+	if len(certList) == 0 {
+		fmt.Println("No certs")
+		return nil
+	}
+
+	width := len("Common Name")
+
+	for _, cert := range certList {
+		if len(cert.Name) > width {
+			width = len(cert.Name)
+		}
+	}
+
+	fmt.Printf("%-*s  %s\n", width, "Common Name", "Expires")
+	fmt.Printf("%s  %s\n", strings.Repeat("-", width), strings.Repeat("-", len("Expires")))
+
+	for _, cert := range certList {
+		fmt.Printf("%-*s  %s\n", width, cert.Name, cert.Expires)
+	}
+	return nil
+}
+
+// CertAdd adds a cert to the controller. A cert is added for the common name and
+// for each subject alternate name in sans.
+func CertAdd(certPath, keyPath, commonName, sans string) error {
+	c, err := client.New()
+
+	if err != nil {
+		return err
+	}
+
+	cert, key, info, err := readCert(certPath, keyPath)
+
+	if err != nil {
+		return err
+	}
+
+	printCertInfo(info)
+
+	if info.NotAfter.Before(time.Now()) {
+		fmt.Printf("!    WARNING: %s expired on %s\n", certPath, info.NotAfter.Format(time.RFC1123))
+	}
+
+	names := []string{commonName}
+
+	if sans != "" {
+		names = append(names, strings.Split(sans, ",")...)
+	}
+
+	for _, name := range names {
+		if err = addCert(c, cert, key, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addCert(c *client.Client, cert, key, commonName string) error {
+	if commonName == "" {
+		fmt.Print("Adding SSL endpoint... ")
+	} else {
+		fmt.Printf("Adding SSL endpoint %s... ", commonName)
+	}
+
+	quit := progress()
+	resCert, err := certs.New(c, cert, key, commonName)
+	quit <- true
+	<-quit
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("done, added %s\n", resCert.Name)
+
+	return nil
+}
+
+// CertInfo prints info about a cert.
+func CertInfo(commonName string) error {
+	c, err := client.New()
+
+	if err != nil {
+		return err
+	}
+
+	cert, err := certs.Get(c, commonName)
+
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(cert)
+	}
+
+	fmt.Printf("=== %s Certificate\n", cert.Name)
+	fmt.Println("common name: ", cert.Name)
+	fmt.Println("expires:     ", cert.Expires)
+	fmt.Println("owner:       ", cert.Owner)
+	fmt.Println("created:     ", cert.Created)
+	fmt.Println("updated:     ", cert.Updated)
+
+	return nil
+}
+
+// CertRemove removes a cert from the controller.
+func CertRemove(commonName string) error {
+	c, err := client.New()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removing %s... ", commonName)
+
+	quit := progress()
+	err = certs.Delete(c, commonName)
+	quit <- true
+	<-quit
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done")
+	return nil
+}
+
+// readCert reads a PEM encoded certificate and private key from disk and checks
+// that they belong together.
+func readCert(certPath, keyPath string) (string, string, *x509.Certificate, error) {
+	cert, err := ioutil.ReadFile(certPath)
+
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	key, err := ioutil.ReadFile(keyPath)
+
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pair, err := tls.X509KeyPair(cert, key)
+
+	if err != nil {
+		return "", "", nil, fmt.Errorf("Could not load %s and %s: %v", certPath, keyPath, err)
+	}
+
+	info, err := x509.ParseCertificate(pair.Certificate[0])
+
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return strings.TrimSpace(string(cert)), strings.TrimSpace(string(key)), info, nil
+}
+
+func printCertInfo(info *x509.Certificate) {
+	sans := "(none)"
+
+	if len(info.DNSNames) > 0 {
+		sans = strings.Join(info.DNSNames, ", ")
+	}
+
+	fmt.Println("common name:   ", info.Subject.CommonName)
+	fmt.Println("expires:       ", info.NotAfter.Format(time.RFC1123))
+	fmt.Println("alt names:     ", sans)
+}
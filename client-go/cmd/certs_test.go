@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeTestCert(t *testing.T, dir string, name string) (string, string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test.example.com"},
+		DNSNames:     []string{"www.example.com", "api.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := path.Join(dir, name+".crt")
+	keyPath := path.Join(dir, name+".key")
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	if err = ioutil.WriteFile(certPath, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certPath, keyPath
+}
+
+func TestReadCert(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "deis-cert")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath, keyPath := writeTestCert(t, dir, "test")
+
+	_, _, info, err := readCert(certPath, keyPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Subject.CommonName != "test.example.com" {
+		t.Errorf("Expected test.example.com, Got %s", info.Subject.CommonName)
+	}
+
+	expected := []string{"www.example.com", "api.example.com"}
+
+	if !reflect.DeepEqual(expected, info.DNSNames) {
+		t.Errorf("Expected %v, Got %v", expected, info.DNSNames)
+	}
+}
+
+func TestReadCertMismatchedKey(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "deis-cert")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath, _ := writeTestCert(t, dir, "first")
+	_, keyPath := writeTestCert(t, dir, "second")
+
+	if _, _, _, err = readCert(certPath, keyPath); err == nil {
+		t.Error("Expected an error for a mismatched key")
+	}
+}
@@ -28,6 +28,10 @@ func ConfigList(appID string, oneLine bool) error {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(config.Values)
+	}
+
 	if oneLine {
 		for key, value := range config.Values {
 			fmt.Printf("%s=%s ", key, value)
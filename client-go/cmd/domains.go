@@ -7,19 +7,23 @@ import (
 )
 
 // DomainsList lists domains registered with an app.
-func DomainsList(appID string) error {
+func DomainsList(appID string, results int) error {
 	c, appID, err := load(appID)
 
 	if err != nil {
 		return err
 	}
 
-	domains, err := domains.List(c, appID)
+	domains, err := domains.List(c, appID, results)
 
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(domains)
+	}
+
 	fmt.Printf("=== %s Domains\n", appID)
 
 	for _, domain := range domains {
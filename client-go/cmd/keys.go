@@ -15,19 +15,23 @@ import (
 )
 
 // KeysList lists a user's keys.
-func KeysList() error {
+func KeysList(results int) error {
 	c, err := client.New()
 
 	if err != nil {
 		return err
 	}
 
-	keys, err := keys.List(c)
+	keys, err := keys.List(c, results)
 
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(keys)
+	}
+
 	fmt.Printf("=== %s Keys\n", c.Username)
 
 	for _, key := range keys {
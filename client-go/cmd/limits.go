@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/deis/deis/pkg/prettyprint"
+
+	"github.com/deis/deis/client-go/controller/api"
+	"github.com/deis/deis/client-go/controller/models/config"
+)
+
+// LimitsList lists an app's limits.
+func LimitsList(appID string) error {
+	c, appID, err := load(appID)
+
+	if err != nil {
+		return err
+	}
+
+	config, err := config.List(c, appID)
+
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(map[string]interface{}{"memory": config.Memory, "cpu": config.CPU})
+	}
+
+	printLimits(appID, config)
+
+	return nil
+}
+
+// LimitsSet sets an app's limits.
+func LimitsSet(appID string, limits []string, limitType string) error {
+	c, appID, err := load(appID)
+
+	if err != nil {
+		return err
+	}
+
+	limitsMap := parseLimits(limits)
+
+	fmt.Print("Applying limits... ")
+
+	quit := progress()
+	configObj := api.Config{}
+
+	if limitType == "cpu" {
+		configObj.CPU = limitsMap
+	} else {
+		configObj.Memory = limitsMap
+	}
+
+	_, err = config.Set(c, appID, configObj)
+
+	quit <- true
+	<-quit
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("done\n\n")
+
+	return LimitsList(appID)
+}
+
+// LimitsUnset removes an app's limits.
+func LimitsUnset(appID string, limits []string, limitType string) error {
+	c, appID, err := load(appID)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("Applying limits... ")
+
+	quit := progress()
+
+	configObj := api.Config{}
+
+	valuesMap := make(map[string]interface{})
+
+	for _, limit := range limits {
+		valuesMap[limit] = nil
+	}
+
+	if limitType == "cpu" {
+		configObj.CPU = valuesMap
+	} else {
+		configObj.Memory = valuesMap
+	}
+
+	_, err = config.Set(c, appID, configObj)
+
+	quit <- true
+	<-quit
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("done\n\n")
+
+	return LimitsList(appID)
+}
+
+func printLimits(appID string, config api.Config) {
+	fmt.Printf("=== %s Limits\n\n", appID)
+
+	fmt.Println("--- Memory")
+	printLimitTable(config.Memory)
+
+	fmt.Println("\n--- CPU")
+	printLimitTable(config.CPU)
+}
+
+func printLimitTable(limits map[string]interface{}) {
+	if len(limits) == 0 {
+		fmt.Println("Unlimited")
+		return
+	}
+
+	limitsMap := make(map[string]string)
+
+	// Limits can be returned as strings or numbers, so they need to be converted to a string.
+	for key, value := range limits {
+		limitsMap[key] = fmt.Sprintf("%v", value)
+	}
+
+	fmt.Print(prettyprint.PrettyTabs(limitsMap, 5))
+}
+
+func parseLimits(limits []string) map[string]interface{} {
+	limitsMap := make(map[string]interface{})
+
+	regex := regexp.MustCompile("^([A-z]+)=([0-9]+[A-z]*)$")
+
+	for _, limit := range limits {
+		if regex.MatchString(limit) {
+			captures := regex.FindStringSubmatch(limit)
+			limitsMap[captures[1]] = captures[2]
+		} else {
+			fmt.Printf("'%s' does not match the pattern 'type=limit', ex: web=512M\n", limit)
+		}
+	}
+
+	return limitsMap
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	t.Parallel()
+
+	expected := map[string]interface{}{
+		"web":    "512M",
+		"worker": "1024",
+	}
+
+	actual := parseLimits([]string{"web=512M", "worker=1024", "invalid", "cmd=abc"})
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, Got %v", expected, actual)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats for listing and info commands.
+const (
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputTemplate = "template"
+)
+
+var (
+	outputFormat   = OutputTable
+	outputTemplate *template.Template
+	outputWriter   io.Writer = os.Stdout
+)
+
+// SetOutput chooses how listing and info commands render their results. The template is
+// required by, and implies, the template format.
+func SetOutput(format string, tmpl string) error {
+	if tmpl != "" {
+		if format != "" && format != OutputTemplate {
+			return fmt.Errorf("--format cannot be used with --output %s", format)
+		}
+
+		t, err := template.New("output").Parse(tmpl)
+
+		if err != nil {
+			return err
+		}
+
+		outputFormat = OutputTemplate
+		outputTemplate = t
+		return nil
+	}
+
+	switch format {
+	case "", OutputTable:
+		outputFormat = OutputTable
+	case OutputJSON, OutputYAML:
+		outputFormat = format
+	case OutputTemplate:
+		return fmt.Errorf("--output %s requires --format", format)
+	default:
+		return fmt.Errorf("Unknown output format %s. Use table, json, yaml or template.", format)
+	}
+
+	outputTemplate = nil
+	return nil
+}
+
+// structuredOutput reports whether results should be rendered by printStructured rather
+// than as a human readable table.
+func structuredOutput() bool {
+	return outputFormat != OutputTable
+}
+
+// printStructured renders v in the chosen output format. Templates are executed once per
+// element when v is a slice.
+func printStructured(v interface{}) error {
+	switch outputFormat {
+	case OutputJSON:
+		out, err := json.MarshalIndent(v, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(outputWriter, string(out))
+	case OutputYAML:
+		// Round trip through JSON so keys match the API's field names.
+		out, err := json.Marshal(v)
+
+		if err != nil {
+			return err
+		}
+
+		var generic interface{}
+		if err = json.Unmarshal(out, &generic); err != nil {
+			return err
+		}
+
+		if out, err = yaml.Marshal(generic); err != nil {
+			return err
+		}
+
+		fmt.Fprint(outputWriter, string(out))
+	case OutputTemplate:
+		value := reflect.ValueOf(v)
+
+		if value.Kind() != reflect.Slice {
+			return executeTemplate(v)
+		}
+
+		for i := 0; i < value.Len(); i++ {
+			if err := executeTemplate(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func executeTemplate(v interface{}) error {
+	if err := outputTemplate.Execute(outputWriter, v); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(outputWriter)
+	return nil
+}
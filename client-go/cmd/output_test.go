@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/deis/deis/client-go/controller/api"
+)
+
+func TestPrintStructured(t *testing.T) {
+	buf := &bytes.Buffer{}
+	outputWriter = buf
+	defer func() {
+		outputWriter = os.Stdout
+		SetOutput(OutputTable, "")
+	}()
+
+	keys := []api.Key{
+		{ID: "test@example.com", Public: "ssh-rsa abc"},
+		{ID: "other@example.com", Public: "ssh-rsa def"},
+	}
+
+	tests := []struct {
+		format   string
+		tmpl     string
+		expected string
+	}{
+		{OutputTemplate, "{{.ID}}", "test@example.com\nother@example.com\n"},
+		{OutputYAML, "", `- created: ""
+  id: test@example.com
+  owner: ""
+  public: ssh-rsa abc
+  updated: ""
+  uuid: ""
+- created: ""
+  id: other@example.com
+  owner: ""
+  public: ssh-rsa def
+  updated: ""
+  uuid: ""
+`},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+
+		if err := SetOutput(test.format, test.tmpl); err != nil {
+			t.Fatal(err)
+		}
+
+		if !structuredOutput() {
+			t.Errorf("Expected %s output to be structured", test.format)
+		}
+
+		if err := printStructured(keys); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("Expected %q, Got %q", test.expected, buf.String())
+		}
+	}
+}
+
+func TestSetOutputErrors(t *testing.T) {
+	defer SetOutput(OutputTable, "")
+
+	if err := SetOutput("xml", ""); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+
+	if err := SetOutput(OutputTemplate, ""); err == nil {
+		t.Error("Expected an error for a template format without a template")
+	}
+
+	if err := SetOutput(OutputJSON, "{{.ID}}"); err == nil {
+		t.Error("Expected an error for a template with the json format")
+	}
+
+	if err := SetOutput("", "{{.ID"); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/deis/deis/client-go/controller/client"
+)
+
+// ProfilesList lists the client's profiles, marking the active one.
+func ProfilesList() error {
+	profiles, err := client.Profiles()
+
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(profiles)
+	}
+
+	if len(profiles) == 0 {
+		fmt.Println("No profiles found. Use 'deis login --profile <profile>' to create one.")
+		return nil
+	}
+
+	active := client.ActiveProfile()
+
+	fmt.Println("=== Profiles")
+
+	for _, profile := range profiles {
+		marker := " "
+		if profile.Name == active {
+			marker = "*"
+		}
+
+		fmt.Printf("%s %s\t%s\t%s\n", marker, profile.Name, profile.Username, profile.Controller)
+	}
+
+	return nil
+}
+
+// ProfileUse makes a profile the default one.
+func ProfileUse(profile string) error {
+	if err := client.UseProfile(profile); err != nil {
+		return err
+	}
+
+	fmt.Printf("Using profile %s\n", profile)
+	return nil
+}
+
+// ProfileRemove deletes a profile.
+func ProfileRemove(profile string) error {
+	if err := client.RemoveProfile(profile); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed profile %s\n", profile)
+	return nil
+}
@@ -25,6 +25,10 @@ func PsList(appID string) error {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(processes)
+	}
+
 	printProcesses(appID, processes)
 
 	return nil
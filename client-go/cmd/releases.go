@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/deis/deis/client-go/controller/models/releases"
+)
+
+// ReleasesList lists an app's releases.
+func ReleasesList(appID string, results int) error {
+	c, appID, err := load(appID)
+
+	if err != nil {
+		return err
+	}
+
+	releases, err := releases.List(c, appID, results)
+
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(releases)
+	}
+
+	fmt.Printf("=== %s Releases\n", appID)
+
+	for _, release := range releases {
+		fmt.Printf("v%-6d %-28s %s\n", release.Version, release.Created, release.Summary)
+	}
+	return nil
+}
+
+// ReleasesInfo prints info about a specific release.
+func ReleasesInfo(appID string, version int) error {
+	c, appID, err := load(appID)
+
+	if err != nil {
+		return err
+	}
+
+	release, err := releases.Get(c, appID, version)
+
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(release)
+	}
+
+	fmt.Printf("=== %s Release v%d\n", appID, version)
+	if release.Build != "" {
+		fmt.Println("build:   ", release.Build)
+	}
+	fmt.Println("config:  ", release.Config)
+	fmt.Println("owner:   ", release.Owner)
+	fmt.Println("created: ", release.Created)
+	fmt.Println("summary: ", release.Summary)
+	fmt.Println("updated: ", release.Updated)
+	fmt.Println("uuid:    ", release.UUID)
+
+	return nil
+}
+
+// ReleasesRollback rolls an app back to a previous release.
+func ReleasesRollback(appID string, version int) error {
+	c, appID, err := load(appID)
+
+	if err != nil {
+		return err
+	}
+
+	if version == -1 {
+		fmt.Print("Rolling back one release... ")
+	} else {
+		fmt.Printf("Rolling back to v%d... ", version)
+	}
+
+	quit := progress()
+	newVersion, err := releases.Rollback(c, appID, version)
+	quit <- true
+	<-quit
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("done, v%d\n", newVersion)
+
+	return nil
+}
@@ -20,6 +20,14 @@ func TagsList(appID string) error {
 
 	config, err := config.List(c, appID)
 
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(config.Tags)
+	}
+
 	fmt.Printf("=== %s Tags\n", appID)
 
 	tagMap := make(map[string]string)
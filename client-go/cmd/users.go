@@ -8,19 +8,23 @@ import (
 )
 
 // UsersList lists users registered with the controller.
-func UsersList() error {
+func UsersList(results int) error {
 	c, err := client.New()
 
 	if err != nil {
 		return err
 	}
 
-	users, err := users.List(c)
+	users, err := users.List(c, results)
 
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(users)
+	}
+
 	fmt.Println("=== Users")
 
 	for _, user := range users {
@@ -12,10 +12,10 @@ type App struct {
 
 // Apps is the definition of GET /v1/apps/.
 type Apps struct {
-	Count    int   `json:"count"`
-	Next     int   `json:"next"`
-	Previous int   `json:"previous"`
-	Apps     []App `json:"results"`
+	Count    int    `json:"count"`
+	Next     string `json:"next"`
+	Previous string `json:"previous"`
+	Apps     []App  `json:"results"`
 }
 
 // AppCreateRequest is the definition of POST /v1/apps/.
@@ -28,6 +28,14 @@ type AppRunRequest struct {
 	Command string `json:"command"`
 }
 
+// AppRunAttachRequest is the definition of POST /v1/apps/<app id>/run/attach.
+type AppRunAttachRequest struct {
+	Command string `json:"command"`
+	TTY     bool   `json:"tty"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+}
+
 // AppRunResponse is the definition of /v1/apps/<app id>/run.
 type AppRunResponse struct {
 	Output     string `json:"output"`
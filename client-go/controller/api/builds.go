@@ -16,8 +16,8 @@ type Build struct {
 // Builds is the structure of GET /v1/apps/<app id>/builds/.
 type Builds struct {
 	Count    int     `json:"count"`
-	Next     int     `json:"next"`
-	Previous int     `json:"previous"`
+	Next     string  `json:"next"`
+	Previous string  `json:"previous"`
 	Builds   []Build `json:"results"`
 }
 
@@ -0,0 +1,26 @@
+package api
+
+// Cert is the definition of the cert object.
+type Cert struct {
+	Updated string `json:"updated,omitempty"`
+	Created string `json:"created,omitempty"`
+	Name    string `json:"common_name"`
+	Expires string `json:"expires"`
+	Owner   string `json:"owner"`
+	ID      int    `json:"id,omitempty"`
+}
+
+// Certs is the definition of GET /v1/certs/.
+type Certs struct {
+	Count    int    `json:"count"`
+	Next     string `json:"next"`
+	Previous string `json:"previous"`
+	Certs    []Cert `json:"results"`
+}
+
+// CertCreateRequest is the definition of POST /v1/certs/.
+type CertCreateRequest struct {
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+	Name        string `json:"common_name,omitempty"`
+}
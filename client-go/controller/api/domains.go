@@ -12,8 +12,8 @@ type Domain struct {
 // Domains is the structure of GET /v1/app/<app id>/domains/.
 type Domains struct {
 	Count    int      `json:"count"`
-	Next     int      `json:"next"`
-	Previous int      `json:"previous"`
+	Next     string   `json:"next"`
+	Previous string   `json:"previous"`
 	Domains  []Domain `json:"results"`
 }
 
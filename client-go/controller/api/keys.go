@@ -12,10 +12,10 @@ type Key struct {
 
 // Keys is the definition of GET /v1/keys/.
 type Keys struct {
-	Count    int   `json:"count"`
-	Next     int   `json:"next"`
-	Previous int   `json:"previous"`
-	Keys     []Key `json:"results"`
+	Count    int    `json:"count"`
+	Next     string `json:"next"`
+	Previous string `json:"previous"`
+	Keys     []Key  `json:"results"`
 }
 
 // KeyCreateRequest is the definition of POST /v1/keys/.
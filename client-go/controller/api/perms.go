@@ -0,0 +1,25 @@
+package api
+
+// PermsAppResponse is the definition of GET /v1/apps/<app id>/perms/.
+type PermsAppResponse struct {
+	Users []string `json:"users"`
+}
+
+// PermsAdminResponse is the definition of GET /v1/admin/perms/.
+type PermsAdminResponse struct {
+	Count    int          `json:"count"`
+	Next     string       `json:"next"`
+	Previous string       `json:"previous"`
+	Users    []PermsAdmin `json:"results"`
+}
+
+// PermsAdmin is the definition of a user with system administrator privileges.
+type PermsAdmin struct {
+	Username    string `json:"username"`
+	IsSuperuser bool   `json:"is_superuser"`
+}
+
+// PermsRequest is the definition of POST /v1/apps/<app id>/perms/ and POST /v1/admin/perms/.
+type PermsRequest struct {
+	Username string `json:"username"`
+}
@@ -16,7 +16,7 @@ type Process struct {
 // Processes defines the structure of processes.
 type Processes struct {
 	Count     int       `json:"count"`
-	Next      int       `json:"next"`
-	Previous  int       `json:"previous"`
+	Next      string    `json:"next"`
+	Previous  string    `json:"previous"`
 	Processes []Process `json:"results"`
 }
@@ -17,7 +17,7 @@ type User struct {
 // Users is the definition of GET /v1/users.
 type Users struct {
 	Count    int    `json:"count"`
-	Next     int    `json:"next"`
-	Previous int    `json:"previous"`
+	Next     string `json:"next"`
+	Previous string `json:"previous"`
 	Users    []User `json:"results"`
 }
@@ -48,14 +48,22 @@ func Register(controllerURL url.URL, username string, password string, email str
 	// Remove the path of the URL.
 	controllerURL.Path = ""
 	if loginAfter {
-		return Login(controllerURL, username, password, sslVerify)
+		return Login(controllerURL, username, password, sslVerify, "")
 	}
 
 	return nil
 }
 
-// Login logs a user into a Deis controller.
-func Login(controllerURL url.URL, username string, password string, sslVerify bool) error {
+// Login logs a user into a Deis controller, saving the session to the given profile, or to the
+// profile chosen by DEIS_PROFILE or UseProfile if it is empty.
+func Login(controllerURL url.URL, username string, password string, sslVerify bool,
+	profile string) error {
+	if profile != "" {
+		if err := validateProfile(profile); err != nil {
+			return err
+		}
+	}
+
 	client := CreateHTTPClient(sslVerify)
 
 	user := api.AuthLoginRequest{Username: username, Password: password}
@@ -89,17 +97,22 @@ func Login(controllerURL url.URL, username string, password string, sslVerify bo
 	// Remove the path of the URL.
 	controllerURL.Path = ""
 	controllerClient := Client{Username: username, SSLVerify: sslVerify,
-		ControllerURL: controllerURL, Token: token.Token}
+		ControllerURL: controllerURL, Token: token.Token, Profile: profile}
 
 	if err = controllerClient.Save(); err != nil {
 		return err
 	}
 
+	if profile != "" {
+		fmt.Printf("Logged in as %s (profile %s)\n", username, profile)
+		return nil
+	}
+
 	fmt.Printf("Logged in as %s\n", username)
 	return nil
 }
 
-// Logout from a Deis controller by deleting config file.
+// Logout from the Deis controller of the active profile by deleting its config file.
 func Logout() error {
 	if err := deleteSettings(); err != nil {
 		return err
@@ -157,7 +170,8 @@ func Cancel() error {
 		return fmt.Errorf("Cancellation failed: %s", body)
 	}
 
-	if err = deleteSettings(); err != nil {
+	// Remove the profile the cancelled account was loaded from.
+	if err = removeSettingsFile(profilePath(client.Profile)); err != nil {
 		return err
 	}
 
@@ -161,7 +161,7 @@ func TestLogin(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if Login(*controllerURL, "test", "opensesame", false); err != nil {
+	if Login(*controllerURL, "test", "opensesame", false, ""); err != nil {
 		t.Error(err)
 	}
 
@@ -7,7 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path"
+	"time"
 )
 
 // Client oversees the interaction between the client and controller
@@ -26,6 +26,20 @@ type Client struct {
 
 	// Username is the name of the user performing requests against the API.
 	Username string
+
+	// Profile is the name of the settings file the client is loaded from and saved to.
+	// The active profile is used if it is empty.
+	Profile string
+
+	// Timeout limits how long a single request attempt may take. Zero means no timeout.
+	Timeout time.Duration
+
+	// Retries is how many times a failed request is retried. See Request for which requests
+	// are retried.
+	Retries int
+
+	// RetryBackoff is the base delay before the first retry. It doubles with every retry.
+	RetryBackoff time.Duration
 }
 
 type settingsFile struct {
@@ -33,11 +47,17 @@ type settingsFile struct {
 	SslVerify  bool   `json:"ssl_verify"`
 	Controller string `json:"controller"`
 	Token      string `json:"token"`
+
+	// Request settings are only ever edited by hand, Save keeps whatever the file has.
+	RequestTimeout string `json:"request_timeout,omitempty"`
+	Retries        *int   `json:"retries,omitempty"`
+	RetryBackoff   string `json:"retry_backoff,omitempty"`
 }
 
 // New creates a new client from a settings file.
 func New() (*Client, error) {
-	filename := locateSettingsFile()
+	profile := activeProfileName()
+	filename := profilePath(profile)
 
 	if _, err := os.Stat(filename); err != nil {
 		if os.IsNotExist(err) {
@@ -47,40 +67,70 @@ func New() (*Client, error) {
 		return nil, err
 	}
 
-	contents, err := ioutil.ReadFile(filename)
+	settings, err := readSettings(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	settings := settingsFile{}
-	if err = json.Unmarshal(contents, &settings); err != nil {
+	u, err := url.Parse(settings.Controller)
+	if err != nil {
 		return nil, err
 	}
 
-	u, err := url.Parse(settings.Controller)
-	if err != nil {
+	c := &Client{HTTPClient: CreateHTTPClient(settings.SslVerify), SSLVerify: settings.SslVerify,
+		ControllerURL: *u, Token: settings.Token, Username: settings.Username, Profile: profile}
+
+	if err = c.loadRequestSettings(settings); err != nil {
 		return nil, err
 	}
 
-	return &Client{HTTPClient: CreateHTTPClient(settings.SslVerify), SSLVerify: settings.SslVerify,
-		ControllerURL: *u, Token: settings.Token, Username: settings.Username}, nil
+	return c, nil
 }
 
-// Save settings to a file
+func readSettings(filename string) (settingsFile, error) {
+	settings := settingsFile{}
+
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return settings, err
+	}
+
+	err = json.Unmarshal(contents, &settings)
+	return settings, err
+}
+
+// Save settings to the file of c.Profile, or to the file of the profile chosen by DEIS_PROFILE
+// or UseProfile if it is empty.
 func (c Client) Save() error {
 	settings := settingsFile{Username: c.Username,
 		SslVerify:  c.SSLVerify,
 		Controller: c.ControllerURL.String(), Token: c.Token}
 
+	filename := locateSettingsFile()
+
+	if c.Profile != "" {
+		if err := validateProfile(c.Profile); err != nil {
+			return err
+		}
+
+		filename = profilePath(c.Profile)
+	}
+
+	if existing, err := readSettings(filename); err == nil {
+		settings.RequestTimeout = existing.RequestTimeout
+		settings.Retries = existing.Retries
+		settings.RetryBackoff = existing.RetryBackoff
+	}
+
 	settingsContents, err := json.Marshal(settings)
 
 	if err != nil {
 		return err
 	}
 
-	if err = os.MkdirAll(path.Join(os.Getenv("HOME"), "/.deis/"), 0775); err != nil {
+	if err = os.MkdirAll(settingsDir(), 0775); err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(locateSettingsFile(), settingsContents, 0775)
+	return ioutil.WriteFile(filename, settingsContents, 0775)
 }
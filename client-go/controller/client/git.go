@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os/exec"
 	"strings"
 )
@@ -83,25 +84,64 @@ func (c Client) DetectApp() (string, error) {
 }
 
 func (c Client) findRemote() (string, error) {
-	out, err := exec.Command("git", "remote", "-v").Output()
+	remotes, err := gitRemotes()
 
 	if err != nil {
 		return "", err
 	}
 
-	cmd := string(out)
-
-	for _, line := range strings.Split(cmd, "\n") {
-		for _, remote := range strings.Split(line, " ") {
-			if strings.Contains(remote, c.ControllerURL.Host) {
-				return strings.Split(remote, "\t")[1], nil
-			}
+	for _, remote := range remotes {
+		if strings.Contains(remote, c.ControllerURL.Host) {
+			return remote, nil
 		}
 	}
 
 	return "", errors.New("Could not find deis remote in 'git remote -v'")
 }
 
+// gitRemotes lists the URLs of the git remotes in the current directory.
+func gitRemotes() ([]string, error) {
+	out, err := exec.Command("git", "remote", "-v").Output()
+
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := []string{}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) < 2 {
+			continue
+		}
+
+		remotes = append(remotes, fields[1])
+	}
+
+	return remotes, nil
+}
+
+// remoteHost returns the host of a git remote URL, such as ssh://git@host:2222/app.git or
+// git@host:app.git.
+func remoteHost(remote string) string {
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+
+		if err != nil {
+			return ""
+		}
+
+		return hostname(u.Host)
+	}
+
+	if index := strings.Index(remote, "@"); index != -1 {
+		remote = remote[index+1:]
+	}
+
+	return strings.Split(remote, ":")[0]
+}
+
 // RemoteURL returns the git URL of app.
 func (c Client) RemoteURL(appID string) string {
 	return fmt.Sprintf("ssh://git@%s:2222/%s.git", c.ControllerURL.Host, appID)
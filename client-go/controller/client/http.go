@@ -1,15 +1,18 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/deis/deis/version"
 )
@@ -47,8 +50,32 @@ func rawRequest(client *http.Client, method string, url string, body io.Reader,
 	return res, nil
 }
 
-// Request makes a HTTP request on the controller.
+// Request makes a HTTP request on the controller. Failed requests are retried up to
+// c.Retries times with a jittered exponential backoff, or after the delay the controller
+// asks for with Retry-After. See shouldRetry for which failures are retried.
 func (c Client) Request(method string, path string, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := c.request(method, path, body)
+
+		if attempt >= c.Retries || !shouldRetry(method, res, err) {
+			if err == nil {
+				checkAPICompatability(res.Header.Get("DEIS_API_VERSION"))
+			}
+			return res, err
+		}
+
+		delay := c.retryDelay(attempt, res)
+
+		if res != nil {
+			res.Body.Close()
+		}
+
+		time.Sleep(delay)
+	}
+}
+
+// request makes a single attempt at a HTTP request on the controller.
+func (c Client) request(method string, path string, body []byte) (*http.Response, error) {
 	url := c.ControllerURL
 
 	if strings.Contains(path, "?") {
@@ -69,15 +96,15 @@ func (c Client) Request(method string, path string, body []byte) (*http.Response
 	req.Header.Add("Authorization", "token "+c.Token)
 	addUserAgent(&req.Header)
 
-	res, err := c.HTTPClient.Do(req)
+	httpClient := c.HTTPClient
 
-	if err != nil {
-		return nil, err
+	if c.Timeout > 0 {
+		timed := *httpClient
+		timed.Timeout = c.Timeout
+		httpClient = &timed
 	}
 
-	checkAPICompatability(res.Header.Get("DEIS_API_VERSION"))
-
-	return res, nil
+	return httpClient.Do(req)
 }
 
 // BasicRequest makes a simple http request on the controller.
@@ -97,6 +124,77 @@ func (c Client) BasicRequest(method string, path string, body []byte) (string, i
 	return string(resBody), res.StatusCode, nil
 }
 
+// Hijack makes a HTTP request on the controller that asks to upgrade the connection to a raw
+// stream. Once the controller switches protocols, the connection and a reader buffering any
+// data already received on it are returned.
+func (c Client) Hijack(method string, path string, body []byte) (net.Conn, *bufio.Reader, error) {
+	u := c.ControllerURL
+	u.Path = path
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(body))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "token "+c.Token)
+	req.Header.Add("Connection", "Upgrade")
+	req.Header.Add("Upgrade", "tcp")
+	addUserAgent(&req.Header)
+
+	conn, err := c.dial()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		defer conn.Close()
+		defer res.Body.Close()
+
+		checkAPICompatability(res.Header.Get("DEIS_API_VERSION"))
+
+		resBody, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New(string(resBody))
+	}
+
+	return conn, br, nil
+}
+
+// dial opens a connection to the controller, using TLS for https controllers.
+func (c Client) dial() (net.Conn, error) {
+	host := c.ControllerURL.Host
+
+	if c.ControllerURL.Scheme == "https" {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, "443")
+		}
+		return tls.Dial("tcp", host, &tls.Config{InsecureSkipVerify: !c.SSLVerify})
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "80")
+	}
+	return net.Dial("tcp", host)
+}
+
 // CheckConection checks that the user is connected to a network and the URL points to a valid controller.
 func CheckConection(client *http.Client, controllerURL url.URL) error {
 	errorMessage := `%s does not appear to be a valid Deis controller.
@@ -74,6 +74,29 @@ func (fakeHTTPServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if req.URL.Path == "/hijack/" && req.Method == "POST" {
+		if req.Header.Get("Upgrade") != "tcp" {
+			fmt.Printf("Upgrade Wrong: Expected tcp, Got %s\n", req.Header.Get("Upgrade"))
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte("no upgrade"))
+			return
+		}
+
+		conn, buf, err := res.(http.Hijacker).Hijack()
+
+		if err != nil {
+			fmt.Println(err)
+			res.WriteHeader(http.StatusInternalServerError)
+			res.Write(nil)
+			return
+		}
+		defer conn.Close()
+
+		buf.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\nraw")
+		buf.Flush()
+		return
+	}
+
 	fmt.Printf("Unrecongized URL %s\n", req.URL)
 	res.WriteHeader(http.StatusNotFound)
 	res.Write(nil)
@@ -172,3 +195,41 @@ func TestBasicRequest(t *testing.T) {
 		t.Errorf("Expected %s, Got %s", expected, body)
 	}
 }
+
+func TestHijack(t *testing.T) {
+	t.Parallel()
+
+	handler := fakeHTTPServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := Client{HTTPClient: CreateHTTPClient(false), ControllerURL: *u, Token: "abc"}
+
+	conn, br, err := client.Hijack("POST", "/hijack/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	actual, err := ioutil.ReadAll(br)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "raw"
+	if string(actual) != expected {
+		t.Errorf("Expected %s, Got %s", expected, actual)
+	}
+
+	if _, _, err = client.Hijack("POST", "/raw/", nil); err == nil {
+		t.Error("Expected an error when the controller does not switch protocols")
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+// ErrStopPaging can be returned by a page handler to stop paging without an error.
+var ErrStopPaging = errors.New("stop paging")
+
+// pageEnvelope holds the pagination fields every list response shares.
+type pageEnvelope struct {
+	Next    *string         `json:"next"`
+	Results json.RawMessage `json:"results"`
+}
+
+// ListPages requests a list resource and every page after it by following the controller's
+// next links, passing each page's raw results to handle. Returning ErrStopPaging from handle
+// stops paging early.
+func (c Client) ListPages(path string, handle func(results []byte) error) error {
+	for path != "" {
+		body, status, err := c.BasicRequest("GET", path, nil)
+
+		if err != nil {
+			return err
+		}
+
+		if status != 200 {
+			return errors.New(body)
+		}
+
+		page := pageEnvelope{}
+		if err = json.Unmarshal([]byte(body), &page); err != nil {
+			return err
+		}
+
+		if err = handle(page.Results); err != nil {
+			if err == ErrStopPaging {
+				return nil
+			}
+			return err
+		}
+
+		if page.Next == nil {
+			return nil
+		}
+
+		if path, err = nextPath(*page.Next); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextPath turns an absolute next link into a path on the controller. The controller may
+// build the link with a host that differs from the one the client uses, such as when it is
+// behind a proxy, so only the path and query are kept.
+func nextPath(next string) (string, error) {
+	u, err := url.Parse(next)
+
+	if err != nil {
+		return "", err
+	}
+
+	if u.RawQuery == "" {
+		return u.Path, nil
+	}
+
+	return u.Path + "?" + u.RawQuery, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type fakePagesServer struct{}
+
+// ServeHTTP serves three pages of results. The next links use a different host than the
+// request, as a controller behind a proxy would.
+func (fakePagesServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/pages/" || req.Method != "GET" {
+		fmt.Printf("Unrecongized URL %s\n", req.URL)
+		res.WriteHeader(http.StatusNotFound)
+		res.Write(nil)
+		return
+	}
+
+	switch req.URL.Query().Get("page") {
+	case "":
+		res.Write([]byte(`{"count": 5, "next": "http://deis.internal/pages/?page=2", "previous": null, "results": [1, 2]}`))
+	case "2":
+		res.Write([]byte(`{"count": 5, "next": "http://deis.internal/pages/?page=3", "previous": "http://deis.internal/pages/", "results": [3, 4]}`))
+	case "3":
+		res.Write([]byte(`{"count": 5, "next": null, "previous": "http://deis.internal/pages/?page=2", "results": [5]}`))
+	default:
+		res.WriteHeader(http.StatusNotFound)
+		res.Write([]byte("Not found"))
+	}
+}
+
+func TestListPages(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(fakePagesServer{})
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := Client{HTTPClient: CreateHTTPClient(false), ControllerURL: *u, Token: "abc"}
+
+	var actual []int
+
+	collect := func(limit int) func([]byte) error {
+		return func(results []byte) error {
+			page := []int{}
+			if err := json.Unmarshal(results, &page); err != nil {
+				return err
+			}
+
+			actual = append(actual, page...)
+
+			if limit > 0 && len(actual) >= limit {
+				return ErrStopPaging
+			}
+
+			return nil
+		}
+	}
+
+	if err = client.ListPages("/pages/", collect(-1)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, Got %v", expected, actual)
+	}
+
+	actual = nil
+
+	if err = client.ListPages("/pages/", collect(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []int{1, 2, 3, 4}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, Got %v", expected, actual)
+	}
+
+	if err = client.ListPages("/pages/?page=4", collect(-1)); err == nil {
+		t.Error("Expected an error for a page that does not exist")
+	}
+}
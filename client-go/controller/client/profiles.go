@@ -0,0 +1,232 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultProfile = "client"
+
+var profileRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Profile is a named settings file in ~/.deis.
+type Profile struct {
+	Name       string `json:"name"`
+	Username   string `json:"username"`
+	Controller string `json:"controller"`
+}
+
+func settingsDir() string {
+	return path.Join(os.Getenv("HOME"), ".deis")
+}
+
+func profilePath(name string) string {
+	return path.Join(settingsDir(), name+".json")
+}
+
+// currentProfilePath is the file that persists the name of the profile chosen with UseProfile.
+func currentProfilePath() string {
+	return path.Join(settingsDir(), "profile")
+}
+
+func validateProfile(name string) error {
+	if !profileRegex.MatchString(name) {
+		return fmt.Errorf("'%s' is not a valid profile name. Use letters, numbers, '.', '-' and '_'.", name)
+	}
+
+	return nil
+}
+
+// profileName chooses the profile settings are saved to when none is given explicitly:
+// DEIS_PROFILE, or else the profile persisted by UseProfile. It never guesses from git remotes,
+// so logging in to another controller cannot overwrite the profile of the current repository.
+func profileName() string {
+	if name := os.Getenv("DEIS_PROFILE"); name != "" {
+		return name
+	}
+
+	return currentProfile()
+}
+
+// activeProfileName chooses the profile settings are loaded from when none is given explicitly.
+// DEIS_PROFILE wins, then a profile whose controller matches a git remote of the current
+// repository, then the profile persisted by UseProfile.
+func activeProfileName() string {
+	if name := os.Getenv("DEIS_PROFILE"); name != "" {
+		return name
+	}
+
+	current := currentProfile()
+
+	if name, err := profileFromRemote(current); err == nil {
+		return name
+	}
+
+	return current
+}
+
+// currentProfile returns the profile persisted by UseProfile, or the default profile.
+func currentProfile() string {
+	contents, err := ioutil.ReadFile(currentProfilePath())
+
+	if err != nil {
+		return defaultProfile
+	}
+
+	name := strings.TrimSpace(string(contents))
+
+	if validateProfile(name) != nil {
+		return defaultProfile
+	}
+
+	return name
+}
+
+// Profiles lists the profiles in ~/.deis, sorted by name.
+func Profiles() ([]Profile, error) {
+	matches, err := filepath.Glob(profilePath("*"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := []Profile{}
+
+	for _, match := range matches {
+		contents, err := ioutil.ReadFile(match)
+
+		if err != nil {
+			return nil, err
+		}
+
+		settings := settingsFile{}
+		if err = json.Unmarshal(contents, &settings); err != nil {
+			continue
+		}
+
+		profiles = append(profiles, Profile{Name: strings.TrimSuffix(path.Base(match), ".json"),
+			Username: settings.Username, Controller: settings.Controller})
+	}
+
+	sort.Sort(profilesByName(profiles))
+
+	return profiles, nil
+}
+
+// ActiveProfile returns the name of the profile commands currently use.
+func ActiveProfile() string {
+	return activeProfileName()
+}
+
+// UseProfile persists name as the profile to use when DEIS_PROFILE is not set.
+func UseProfile(name string) error {
+	if err := validateProfile(name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(profilePath(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("Profile %s does not exist. Use 'deis login --profile %s' to create it.",
+				name, name)
+		}
+
+		return err
+	}
+
+	return ioutil.WriteFile(currentProfilePath(), []byte(name+"\n"), 0644)
+}
+
+// RemoveProfile deletes a profile. If it was the current profile, the default profile is used
+// from then on.
+func RemoveProfile(name string) error {
+	if err := validateProfile(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(profilePath(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("Profile %s does not exist.", name)
+		}
+
+		return err
+	}
+
+	if currentProfile() == name {
+		if err := os.Remove(currentProfilePath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// profileFromRemote finds a profile whose controller host appears in a git remote of the
+// current repository. The preferred profile wins when several match.
+func profileFromRemote(preferred string) (string, error) {
+	profiles, err := Profiles()
+
+	if err != nil {
+		return "", err
+	}
+
+	// Only shell out to git when there is a choice to make.
+	if len(profiles) < 2 {
+		return "", errors.New("No profiles to choose from")
+	}
+
+	remotes, err := gitRemotes()
+
+	if err != nil {
+		return "", err
+	}
+
+	matches := []string{}
+
+	for _, profile := range profiles {
+		u, err := url.Parse(profile.Controller)
+
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		for _, remote := range remotes {
+			if remoteHost(remote) == hostname(u.Host) {
+				if profile.Name == preferred {
+					return preferred, nil
+				}
+				matches = append(matches, profile.Name)
+				break
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", errors.New("No profile matches a git remote")
+	}
+
+	return matches[0], nil
+}
+
+// hostname strips the port from a host.
+func hostname(host string) string {
+	if index := strings.LastIndex(host, ":"); index != -1 && !strings.HasSuffix(host, "]") {
+		return host[:index]
+	}
+
+	return host
+}
+
+type profilesByName []Profile
+
+func (p profilesByName) Len() int           { return len(p) }
+func (p profilesByName) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p profilesByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
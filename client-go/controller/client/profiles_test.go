@@ -0,0 +1,212 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"testing"
+)
+
+const sFileProd string = `{"username":"p","ssl_verify":true,"controller":"https://deis.prod.t","token":"b"}`
+
+func TestProfiles(t *testing.T) {
+	if err := createTempProfile(sFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(profilePath("prod"), []byte(sFileProd), 0775); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Profile{
+		{Name: "client", Username: "t", Controller: "http://d.t"},
+		{Name: "prod", Username: "p", Controller: "https://deis.prod.t"},
+	}
+
+	actual, err := Profiles()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, Got %v", expected, actual)
+	}
+}
+
+func TestUseAndRemoveProfile(t *testing.T) {
+	if err := createTempProfile(sFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(profilePath("prod"), []byte(sFileProd), 0775); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UseProfile("staging"); err == nil {
+		t.Error("Expected an error using a profile that does not exist")
+	}
+
+	if err := UseProfile("../prod"); err == nil {
+		t.Error("Expected an error using an invalid profile name")
+	}
+
+	if err := UseProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "prod"
+	if client.Profile != expected {
+		t.Errorf("Expected %s, Got %s", expected, client.Profile)
+	}
+
+	expected = "p"
+	if client.Username != expected {
+		t.Errorf("Expected %s, Got %s", expected, client.Username)
+	}
+
+	// DEIS_PROFILE overrides the persisted profile.
+	os.Setenv("DEIS_PROFILE", "client")
+	actual := ActiveProfile()
+	os.Unsetenv("DEIS_PROFILE")
+
+	expected = "client"
+	if actual != expected {
+		t.Errorf("Expected %s, Got %s", expected, actual)
+	}
+
+	if err = RemoveProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(profilePath("prod")); err == nil {
+		t.Error("Expected the prod profile to be removed")
+	}
+
+	expected = "client"
+	if actual = currentProfile(); actual != expected {
+		t.Errorf("Expected %s, Got %s", expected, actual)
+	}
+}
+
+func TestSaveToProfile(t *testing.T) {
+	if err := createTempProfile(sFile); err != nil {
+		t.Fatal(err)
+	}
+
+	client := Client{Username: "p", Profile: "prod"}
+
+	if err := client.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(profilePath("prod")); err != nil {
+		t.Error(err)
+	}
+
+	client.Profile = "prod/../client"
+
+	if err := client.Save(); err == nil {
+		t.Error("Expected an error saving to an invalid profile name")
+	}
+}
+
+func TestRemoteHost(t *testing.T) {
+	t.Parallel()
+
+	checks := map[string]string{
+		"ssh://git@deis.example.com:2222/app.git": "deis.example.com",
+		"git@github.com:deis/deis.git":            "github.com",
+		"https://github.com/deis/deis.git":        "github.com",
+	}
+
+	for remote, expected := range checks {
+		if actual := remoteHost(remote); actual != expected {
+			t.Errorf("Expected %s, Got %s", expected, actual)
+		}
+	}
+}
+
+func TestProfileFromRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	if err := createTempProfile(sFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(profilePath("prod"), []byte(sFileProd), 0775); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	repo := path.Join(os.Getenv("HOME"), "repo")
+
+	if err = os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := exec.Command("git", "init").CombinedOutput(); err != nil {
+		t.Fatal(string(out))
+	}
+
+	if _, err = profileFromRemote("client"); err == nil {
+		t.Error("Expected no profile to match a repository without remotes")
+	}
+
+	remote := "ssh://git@deis.prod.t:2222/example-go.git"
+	if out, err := exec.Command("git", "remote", "add", "deis", remote).CombinedOutput(); err != nil {
+		t.Fatal(string(out))
+	}
+
+	expected := "prod"
+	if actual := ActiveProfile(); actual != expected {
+		t.Errorf("Expected %s, Got %s", expected, actual)
+	}
+
+	// Logging in to another controller from the repository must not overwrite the matched profile.
+	client := Client{Username: "staging", Token: "STAGINGTOKEN"}
+
+	if err = client.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if prod, err := readSettings(profilePath("prod")); err != nil || prod.Token == "STAGINGTOKEN" {
+		t.Errorf("Expected the prod profile to be kept, Got %+v, %v", prod, err)
+	}
+
+	if saved, err := readSettings(profilePath("client")); err != nil || saved.Token != "STAGINGTOKEN" {
+		t.Errorf("Expected the current profile to be saved, Got %+v, %v", saved, err)
+	}
+	// Logging out from the repository logs out of the matched profile New loads.
+	if err = Logout(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(profilePath("prod")); !os.IsNotExist(err) {
+		t.Errorf("Expected the prod profile to be deleted, Got %v", err)
+	}
+
+	if _, err = os.Stat(profilePath("client")); err != nil {
+		t.Errorf("Expected the current profile to be kept, Got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Defaults for the request settings of clients loaded from a settings file.
+const (
+	DefaultRetries      = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// maxRetryDelay caps both the backoff and a controller's Retry-After.
+const maxRetryDelay = 30 * time.Second
+
+// loadRequestSettings applies the defaults, then the settings file, then the DEIS_REQUEST_TIMEOUT,
+// DEIS_RETRIES and DEIS_RETRY_BACKOFF environment variables.
+func (c *Client) loadRequestSettings(settings settingsFile) error {
+	c.Timeout = 0
+	c.Retries = DefaultRetries
+	c.RetryBackoff = DefaultRetryBackoff
+
+	timeout := settings.RequestTimeout
+	if env := os.Getenv("DEIS_REQUEST_TIMEOUT"); env != "" {
+		timeout = env
+	}
+
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid request timeout %s, use a duration such as 30s", timeout)
+		}
+
+		c.Timeout = d
+	}
+
+	if settings.Retries != nil {
+		c.Retries = *settings.Retries
+	}
+
+	if env := os.Getenv("DEIS_RETRIES"); env != "" {
+		retries, err := strconv.Atoi(env)
+
+		if err != nil {
+			return fmt.Errorf("invalid DEIS_RETRIES %s, use a number of retries", env)
+		}
+
+		c.Retries = retries
+	}
+
+	if c.Retries < 0 {
+		return fmt.Errorf("invalid number of retries %d", c.Retries)
+	}
+
+	backoff := settings.RetryBackoff
+	if env := os.Getenv("DEIS_RETRY_BACKOFF"); env != "" {
+		backoff = env
+	}
+
+	if backoff != "" {
+		d, err := time.ParseDuration(backoff)
+
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid retry backoff %s, use a duration such as 500ms", backoff)
+		}
+
+		c.RetryBackoff = d
+	}
+
+	return nil
+}
+
+// idempotent reports whether a request with method can be safely sent more than once.
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+
+	return false
+}
+
+// shouldRetry decides whether a request attempt failed in a way worth retrying. The
+// controller refuses requests it is rate limiting before handling them, so those are
+// retried whatever the method. Otherwise only idempotent requests are retried, after a
+// connection error or while the controller or the router in front of it is unavailable.
+func shouldRetry(method string, res *http.Response, err error) bool {
+	if res != nil && res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if !idempotent(method) {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryDelay returns how long to wait before retry number attempt, counting from zero. A
+// Retry-After header on the failed response wins over the jittered exponential backoff.
+func (c Client) retryDelay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if delay, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			if delay > maxRetryDelay {
+				return maxRetryDelay
+			}
+			return delay
+		}
+	}
+
+	backoff := c.RetryBackoff << uint(attempt)
+
+	if backoff > maxRetryDelay || backoff <= 0 {
+		backoff = maxRetryDelay
+	}
+
+	// Wait between half and all of the backoff so clients retrying together spread out.
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses a Retry-After header, which is either a number of seconds or a date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(header)
+
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+
+	return 0, true
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deis/deis/version"
+)
+
+// fakeFlakyServer fails the first failures requests to each path with status.
+type fakeFlakyServer struct {
+	sync.Mutex
+	status   int
+	failures int
+	attempts map[string]int
+}
+
+func (f *fakeFlakyServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	f.attempts[req.URL.Path]++
+	attempt := f.attempts[req.URL.Path]
+	f.Unlock()
+
+	res.Header().Add("DEIS_API_VERSION", version.APIVersion)
+
+	if req.URL.Path == "/slow/" {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if attempt <= f.failures {
+		res.Header().Add("Retry-After", "0")
+		res.WriteHeader(f.status)
+		res.Write([]byte("unavailable"))
+		return
+	}
+
+	res.Write([]byte("ok"))
+}
+
+func (f *fakeFlakyServer) attemptsFor(path string) int {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.attempts[path]
+}
+
+func newFlakyClient(t *testing.T, status int, failures int) (*httptest.Server, *fakeFlakyServer, Client) {
+	handler := &fakeFlakyServer{status: status, failures: failures, attempts: map[string]int{}}
+	server := httptest.NewServer(handler)
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := Client{HTTPClient: CreateHTTPClient(false), ControllerURL: *u, Token: "abc",
+		Retries: 3, RetryBackoff: time.Millisecond}
+
+	return server, handler, client
+}
+
+func TestRequestRetries(t *testing.T) {
+	t.Parallel()
+
+	server, handler, client := newFlakyClient(t, http.StatusServiceUnavailable, 2)
+	defer server.Close()
+
+	body, status, err := client.BasicRequest("GET", "/get/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != 200 || body != "ok" {
+		t.Errorf("Expected 200 ok, Got %d %s", status, body)
+	}
+
+	if handler.attemptsFor("/get/") != 3 {
+		t.Errorf("Expected 3 attempts, Got %d", handler.attemptsFor("/get/"))
+	}
+
+	// POST is not idempotent, so it is not retried.
+	_, status, err = client.BasicRequest("POST", "/post/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d, Got %d", http.StatusServiceUnavailable, status)
+	}
+
+	if handler.attemptsFor("/post/") != 1 {
+		t.Errorf("Expected 1 attempt, Got %d", handler.attemptsFor("/post/"))
+	}
+}
+
+func TestRequestRetriesRateLimited(t *testing.T) {
+	t.Parallel()
+
+	server, handler, client := newFlakyClient(t, http.StatusTooManyRequests, 5)
+	defer server.Close()
+
+	_, status, err := client.BasicRequest("POST", "/post/", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The retries run out before the server stops rate limiting.
+	if status != http.StatusTooManyRequests {
+		t.Errorf("Expected %d, Got %d", http.StatusTooManyRequests, status)
+	}
+
+	if handler.attemptsFor("/post/") != 4 {
+		t.Errorf("Expected 4 attempts, Got %d", handler.attemptsFor("/post/"))
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	server, handler, client := newFlakyClient(t, http.StatusServiceUnavailable, 0)
+	defer server.Close()
+
+	client.Timeout = 10 * time.Millisecond
+	client.Retries = 1
+
+	if _, _, err := client.BasicRequest("GET", "/slow/", nil); err == nil {
+		t.Error("Expected the request to time out")
+	}
+
+	if handler.attemptsFor("/slow/") != 2 {
+		t.Errorf("Expected 2 attempts, Got %d", handler.attemptsFor("/slow/"))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2015, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	checks := []struct {
+		header   string
+		delay    time.Duration
+		expected bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"Mon, 01 Jun 2015 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Jun 2015 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, check := range checks {
+		delay, ok := retryAfter(check.header, now)
+
+		if delay != check.delay || ok != check.expected {
+			t.Errorf("%q: Expected %v %t, Got %v %t", check.header, check.delay, check.expected,
+				delay, ok)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	client := Client{RetryBackoff: time.Second}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		backoff := time.Second << uint(attempt)
+		if backoff > maxRetryDelay {
+			backoff = maxRetryDelay
+		}
+
+		delay := client.retryDelay(attempt, nil)
+
+		if delay < backoff/2 || delay > backoff {
+			t.Errorf("Attempt %d: Expected a delay between %v and %v, Got %v", attempt, backoff/2,
+				backoff, delay)
+		}
+	}
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", "3600")
+
+	if delay := client.retryDelay(0, res); delay != maxRetryDelay {
+		t.Errorf("Expected %v, Got %v", maxRetryDelay, delay)
+	}
+}
+
+func TestLoadRequestSettings(t *testing.T) {
+	contents := `{"username":"t","ssl_verify":false,"controller":"http://d.t","token":"a",` +
+		`"request_timeout":"1m","retries":0,"retry_backoff":"2s"}`
+
+	if err := createTempProfile(contents); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.Timeout != time.Minute || client.Retries != 0 || client.RetryBackoff != 2*time.Second {
+		t.Errorf("Expected 1m0s 0 2s, Got %v %d %v", client.Timeout, client.Retries, client.RetryBackoff)
+	}
+
+	os.Setenv("DEIS_RETRIES", "5")
+	os.Setenv("DEIS_REQUEST_TIMEOUT", "10s")
+	defer os.Unsetenv("DEIS_RETRIES")
+	defer os.Unsetenv("DEIS_REQUEST_TIMEOUT")
+
+	if client, err = New(); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.Timeout != 10*time.Second || client.Retries != 5 {
+		t.Errorf("Expected 10s 5, Got %v %d", client.Timeout, client.Retries)
+	}
+
+	// Saving keeps the request settings in the file.
+	client.Token = "b"
+
+	if err = client.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ioutil.ReadFile(locateSettingsFile())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"username":"t","ssl_verify":false,"controller":"http://d.t","token":"b",` +
+		`"request_timeout":"1m","retries":0,"retry_backoff":"2s"}`
+	if string(saved) != expected {
+		t.Errorf("Expected %s, Got %s", expected, saved)
+	}
+
+	os.Setenv("DEIS_RETRIES", "many")
+
+	if _, err = New(); err == nil {
+		t.Error("Expected an error for an invalid DEIS_RETRIES")
+	}
+}
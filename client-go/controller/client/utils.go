@@ -3,24 +3,21 @@ package client
 import (
 	"fmt"
 	"os"
-	"path"
 
 	"github.com/deis/deis/version"
 )
 
 func locateSettingsFile() string {
-	filename := os.Getenv("DEIS_PROFILE")
-
-	if filename == "" {
-		filename = "client"
-	}
-
-	return path.Join(os.Getenv("HOME"), ".deis", filename+".json")
+	return profilePath(profileName())
 }
 
+// deleteSettings removes the profile settings are loaded from, which may be one matched by git
+// remotes, so logging out logs out of the controller New talks to.
 func deleteSettings() error {
-	filename := locateSettingsFile()
+	return removeSettingsFile(profilePath(activeProfileName()))
+}
 
+func removeSettingsFile(filename string) error {
 	if _, err := os.Stat(filename); err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -11,24 +14,40 @@ import (
 	"github.com/deis/deis/client-go/controller/client"
 )
 
-// List lists apps on a Deis controller.
-func List(c *client.Client) ([]api.App, error) {
-	body, status, err := c.BasicRequest("GET", "/v1/apps/", nil)
+// List lists apps on a Deis controller, following pagination. If limit is positive, at most
+// limit apps are returned.
+func List(c *client.Client, limit int) ([]api.App, error) {
+	apps := []api.App{}
+
+	err := ListPages(c, func(page []api.App) error {
+		apps = append(apps, page...)
+
+		if limit > 0 && len(apps) >= limit {
+			apps = apps[:limit]
+			return client.ErrStopPaging
+		}
+
+		return nil
+	})
 
 	if err != nil {
 		return []api.App{}, err
 	}
 
-	if status != 200 {
-		return []api.App{}, errors.New(body)
-	}
+	return apps, nil
+}
 
-	apps := api.Apps{}
-	if err = json.Unmarshal([]byte(body), &apps); err != nil {
-		return []api.App{}, err
-	}
+// ListPages calls handle with each page of apps on a Deis controller. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, handle func([]api.App) error) error {
+	return c.ListPages("/v1/apps/", func(results []byte) error {
+		page := []api.App{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
 
-	return apps.Apps, nil
+		return handle(page)
+	})
 }
 
 // New creates a new app.
@@ -86,14 +105,23 @@ func Get(c *client.Client, appID string) (api.App, error) {
 	return app, nil
 }
 
-// Logs retrieves logs from an app.
-func Logs(c *client.Client, appID string, lines int) (string, error) {
-	u := fmt.Sprintf("/v1/apps/%s/logs", appID)
+// Logs retrieves logs from an app. ps filters by process type and source by
+// either "deis" or "app". since and until bound the time of the lines, as a time
+// or a duration such as 1h meaning that long ago, and grep is a regular expression
+// lines must match. Filters are ignored if empty.
+func Logs(c *client.Client, appID string, lines int, ps string, source string, since string,
+	until string, grep string) (string, error) {
+
+	query := logsParams(lines, ps, source)
 
-	if lines > 0 {
-		u += "?log_lines=" + strconv.Itoa(lines)
+	for key, value := range map[string]string{"since": since, "until": until, "grep": grep} {
+		if value != "" {
+			query.Set(key, value)
+		}
 	}
 
+	u := fmt.Sprintf("/v1/apps/%s/logs", appID) + encodeQuery(query)
+
 	body, status, err := c.BasicRequest("GET", u, nil)
 
 	if err != nil {
@@ -107,6 +135,70 @@ func Logs(c *client.Client, appID string, lines int) (string, error) {
 	return strings.Trim(body, `"`), nil
 }
 
+// LogsFollow opens a stream of an app's logs which stays open as new lines are
+// written. The controller closes the stream after a while, callers are expected to
+// reconnect. The returned reader must be closed by the caller.
+func LogsFollow(c *client.Client, appID string, lines int, ps string,
+	source string) (io.ReadCloser, error) {
+
+	u := fmt.Sprintf("/v1/apps/%s/logs/tail", appID) + encodeQuery(logsParams(lines, ps, source))
+
+	// The request timeout would cut the stream off, so it does not apply here.
+	stream := *c
+	stream.Timeout = 0
+
+	res, err := stream.Request("GET", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 204 {
+		res.Body.Close()
+		return nil, fmt.Errorf("No logs for %s", appID)
+	}
+
+	if res.StatusCode != 200 {
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New(string(body))
+	}
+
+	return res.Body, nil
+}
+
+func logsParams(lines int, ps string, source string) url.Values {
+	query := url.Values{}
+
+	if lines >= 0 {
+		query.Set("log_lines", strconv.Itoa(lines))
+	}
+
+	if ps != "" {
+		query.Set("ps", ps)
+	}
+
+	if source != "" {
+		query.Set("source", source)
+	}
+
+	return query
+}
+
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	return "?" + query.Encode()
+}
+
 // Run one time command in an app.
 func Run(c *client.Client, appID string, command string) (api.AppRunResponse, error) {
 	req := api.AppRunRequest{Command: command}
@@ -107,6 +107,20 @@ func (f *fakeHTTPServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if req.URL.Path == "/v1/apps/example-go/logs" && req.URL.RawQuery == "grep=fail&log_lines=1&since=1h" &&
+		req.Method == "GET" {
+		res.Write([]byte("failed\n"))
+		return
+	}
+
+	if req.URL.Path == "/v1/apps/example-go/logs/tail" && req.URL.RawQuery == "log_lines=2&ps=web" &&
+		req.Method == "GET" {
+		res.Write([]byte("test\nfoo\n"))
+		res.(http.Flusher).Flush()
+		res.Write([]byte("bar\n"))
+		return
+	}
+
 	if req.URL.Path == "/v1/apps/example-go/run" && req.Method == "POST" {
 		body, err := ioutil.ReadAll(req.Body)
 
@@ -291,7 +305,7 @@ func TestAppsList(t *testing.T) {
 
 	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
 
-	actual, err := List(&client)
+	actual, err := List(&client, -1)
 
 	if err != nil {
 		t.Fatal(err)
@@ -336,7 +350,7 @@ func TestAppsLogs(t *testing.T) {
 	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
 
 	for _, test := range tests {
-		actual, err := Logs(&client, "example-go", test.Input)
+		actual, err := Logs(&client, "example-go", test.Input, "", "", "", "", "")
 
 		if err != nil {
 			t.Error(err)
@@ -346,4 +360,51 @@ func TestAppsLogs(t *testing.T) {
 			t.Errorf("Expected %s, Got %s", test.Expected, actual)
 		}
 	}
+
+	actual, err := Logs(&client, "example-go", 1, "", "", "1h", "", "fail")
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if expected := "failed\n"; actual != expected {
+		t.Errorf("Expected %s, Got %s", expected, actual)
+	}
+}
+
+func TestAppsLogsFollow(t *testing.T) {
+	t.Parallel()
+
+	expected := "test\nfoo\nbar\n"
+
+	handler := fakeHTTPServer{}
+	server := httptest.NewServer(&handler)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := client.CreateHTTPClient(false)
+
+	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
+
+	stream, err := LogsFollow(&client, "example-go", 2, "web", "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	actual, err := ioutil.ReadAll(stream)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actual) != expected {
+		t.Errorf("Expected %s, Got %s", expected, actual)
+	}
 }
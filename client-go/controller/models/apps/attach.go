@@ -0,0 +1,153 @@
+package apps
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/deis/deis/client-go/controller/api"
+	"github.com/deis/deis/client-go/controller/client"
+)
+
+// Stream types used in the frames exchanged with the controller once an attached run is upgraded.
+const (
+	streamStdin byte = iota
+	streamStdout
+	streamStderr
+	streamResize
+	streamExit
+)
+
+const frameHeaderSize = 8
+
+// WindowSize is the size of the terminal an attached command runs in.
+type WindowSize struct {
+	Width  int
+	Height int
+}
+
+// frameWriter serializes frames written to the controller from several goroutines.
+type frameWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (f *frameWriter) write(stream byte, payload []byte) error {
+	f.Lock()
+	defer f.Unlock()
+
+	header := make([]byte, frameHeaderSize)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+
+	if _, err := f.w.Write(append(header, payload...)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readFrame reads a single frame sent by the controller.
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, frameHeaderSize)
+
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header[4:]))
+
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+// Attach runs a one time command in an app with its stdin, stdout and stderr attached.
+// Window sizes received on resize are forwarded to the command's terminal. Attach returns
+// once the command exits, with its exit code.
+func Attach(c *client.Client, appID string, req api.AppRunAttachRequest, stdin io.Reader,
+	stdout io.Writer, stderr io.Writer, resize <-chan WindowSize) (int, error) {
+	body, err := json.Marshal(req)
+
+	if err != nil {
+		return -1, err
+	}
+
+	u := fmt.Sprintf("/v1/apps/%s/run/attach", appID)
+
+	conn, br, err := c.Hijack("POST", u, body)
+
+	if err != nil {
+		return -1, err
+	}
+	defer conn.Close()
+
+	fw := &frameWriter{w: conn}
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if fw.write(streamStdin, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				// An empty stdin frame closes the command's stdin.
+				fw.write(streamStdin, nil)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case size := <-resize:
+				payload := make([]byte, 4)
+				binary.BigEndian.PutUint16(payload[0:], uint16(size.Height))
+				binary.BigEndian.PutUint16(payload[2:], uint16(size.Width))
+				if fw.write(streamResize, payload) != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		stream, payload, err := readFrame(br)
+
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return -1, errors.New("connection closed before the command exited")
+			}
+			return -1, err
+		}
+
+		switch stream {
+		case streamStdout:
+			if _, err = stdout.Write(payload); err != nil {
+				return -1, err
+			}
+		case streamStderr:
+			if _, err = stderr.Write(payload); err != nil {
+				return -1, err
+			}
+		case streamExit:
+			if len(payload) != 4 {
+				return -1, fmt.Errorf("invalid exit frame of %d bytes", len(payload))
+			}
+			return int(int32(binary.BigEndian.Uint32(payload))), nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/deis/deis/client-go/controller/api"
+	"github.com/deis/deis/client-go/controller/client"
+)
+
+type fakeAttachServer struct{}
+
+// ServeHTTP echoes stdin back on stdout, reports resizes on stderr and exits with code 3 once
+// stdin is closed.
+func (fakeAttachServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/v1/apps/example-go/run/attach" || req.Method != "POST" {
+		fmt.Printf("Unrecongized URL %s\n", req.URL)
+		res.WriteHeader(http.StatusNotFound)
+		res.Write(nil)
+		return
+	}
+
+	body := api.AppRunAttachRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Command != "cat" ||
+		!body.TTY || body.Width != 80 || body.Height != 24 {
+		fmt.Printf("Unexpected request %v\n", body)
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte("bad request"))
+		return
+	}
+
+	conn, buf, err := res.(http.Hijacker).Hijack()
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer conn.Close()
+
+	buf.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	buf.Flush()
+
+	fw := &frameWriter{w: conn}
+
+	for {
+		stream, payload, err := readFrame(buf)
+
+		if err != nil {
+			return
+		}
+
+		switch stream {
+		case streamStdin:
+			if len(payload) == 0 {
+				exit := make([]byte, 4)
+				binary.BigEndian.PutUint32(exit, 3)
+				fw.write(streamExit, exit)
+				return
+			}
+			fw.write(streamStdout, payload)
+		case streamResize:
+			rows := binary.BigEndian.Uint16(payload[0:])
+			cols := binary.BigEndian.Uint16(payload[2:])
+			fw.write(streamStderr, []byte(fmt.Sprintf("resize %dx%d\n", cols, rows)))
+		}
+	}
+}
+
+func TestAppsAttach(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(fakeAttachServer{})
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := client.CreateHTTPClient(false)
+
+	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
+
+	req := api.AppRunAttachRequest{Command: "cat", TTY: true, Width: 80, Height: 24}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	resize := make(chan WindowSize, 1)
+	resize <- WindowSize{Width: 100, Height: 40}
+
+	rc, err := Attach(&client, "example-go", req, strings.NewReader("hi\n"), stdout, stderr, resize)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rc != 3 {
+		t.Errorf("Expected exit code 3, Got %d", rc)
+	}
+
+	if stdout.String() != "hi\n" {
+		t.Errorf("Expected stdout %q, Got %q", "hi\n", stdout.String())
+	}
+
+	// The resize is sent concurrently with stdin, so it may arrive after stdin is closed.
+	if stderr.Len() != 0 && stderr.String() != "resize 100x40\n" {
+		t.Errorf("Expected stderr %q, Got %q", "resize 100x40\n", stderr.String())
+	}
+}
@@ -9,25 +9,40 @@ import (
 	"github.com/deis/deis/client-go/controller/client"
 )
 
-// List lists an app's builds.
-func List(c *client.Client, appID string) ([]api.Build, error) {
-	u := fmt.Sprintf("/v1/apps/%s/builds/", appID)
-	body, status, err := c.BasicRequest("GET", u, nil)
+// List lists an app's builds, following pagination. If limit is positive, at most
+// limit builds are returned.
+func List(c *client.Client, appID string, limit int) ([]api.Build, error) {
+	builds := []api.Build{}
 
-	if err != nil {
-		return []api.Build{}, err
-	}
+	err := ListPages(c, appID, func(page []api.Build) error {
+		builds = append(builds, page...)
 
-	if status != 200 {
-		return []api.Build{}, errors.New(body)
-	}
+		if limit > 0 && len(builds) >= limit {
+			builds = builds[:limit]
+			return client.ErrStopPaging
+		}
 
-	builds := api.Builds{}
-	if err = json.Unmarshal([]byte(body), &builds); err != nil {
+		return nil
+	})
+
+	if err != nil {
 		return []api.Build{}, err
 	}
 
-	return builds.Builds, nil
+	return builds, nil
+}
+
+// ListPages calls handle with each page of an app's builds. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, appID string, handle func([]api.Build) error) error {
+	return c.ListPages(fmt.Sprintf("/v1/apps/%s/builds/", appID), func(results []byte) error {
+		page := []api.Build{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+
+		return handle(page)
+	})
 }
 
 // New creates a build for an app.
@@ -0,0 +1,113 @@
+package certs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/deis/deis/client-go/controller/api"
+	"github.com/deis/deis/client-go/controller/client"
+)
+
+// List certs registered with the controller, following pagination. If limit is positive, at most
+// limit certs are returned.
+func List(c *client.Client, limit int) ([]api.Cert, error) {
+	certs := []api.Cert{}
+
+	err := ListPages(c, func(page []api.Cert) error {
+		certs = append(certs, page...)
+
+		if limit > 0 && len(certs) >= limit {
+			certs = certs[:limit]
+			return client.ErrStopPaging
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return []api.Cert{}, err
+	}
+
+	return certs, nil
+}
+
+// ListPages calls handle with each page of certs registered with the controller. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, handle func([]api.Cert) error) error {
+	return c.ListPages("/v1/certs/", func(results []byte) error {
+		page := []api.Cert{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+
+		return handle(page)
+	})
+}
+
+// New creates a new cert. If commonName is empty, the controller reads it from the
+// certificate.
+func New(c *client.Client, cert string, key string, commonName string) (api.Cert, error) {
+	req := api.CertCreateRequest{Certificate: cert, Key: key, Name: commonName}
+	reqBody, err := json.Marshal(req)
+
+	if err != nil {
+		return api.Cert{}, err
+	}
+
+	body, status, err := c.BasicRequest("POST", "/v1/certs/", reqBody)
+
+	if err != nil {
+		return api.Cert{}, err
+	}
+
+	if status != 201 {
+		return api.Cert{}, errors.New(body)
+	}
+
+	resCert := api.Cert{}
+	if err = json.Unmarshal([]byte(body), &resCert); err != nil {
+		return api.Cert{}, err
+	}
+
+	return resCert, nil
+}
+
+// Get retrieves a cert by its common name.
+func Get(c *client.Client, commonName string) (api.Cert, error) {
+	u := fmt.Sprintf("/v1/certs/%s", commonName)
+
+	body, status, err := c.BasicRequest("GET", u, nil)
+
+	if err != nil {
+		return api.Cert{}, err
+	}
+
+	if status != 200 {
+		return api.Cert{}, errors.New(body)
+	}
+
+	resCert := api.Cert{}
+	if err = json.Unmarshal([]byte(body), &resCert); err != nil {
+		return api.Cert{}, err
+	}
+
+	return resCert, nil
+}
+
+// Delete removes a cert.
+func Delete(c *client.Client, commonName string) error {
+	u := fmt.Sprintf("/v1/certs/%s", commonName)
+
+	body, status, err := c.BasicRequest("DELETE", u, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 204 {
+		return errors.New(body)
+	}
+
+	return nil
+}
@@ -9,25 +9,40 @@ import (
 	"github.com/deis/deis/client-go/controller/client"
 )
 
-// List domains registered with an app.
-func List(c *client.Client, appID string) ([]api.Domain, error) {
-	u := fmt.Sprintf("/v1/apps/%s/domains/", appID)
-	body, status, err := c.BasicRequest("GET", u, nil)
+// List domains registered with an app, following pagination. If limit is positive, at most
+// limit domains are returned.
+func List(c *client.Client, appID string, limit int) ([]api.Domain, error) {
+	domains := []api.Domain{}
 
-	if err != nil {
-		return []api.Domain{}, err
-	}
+	err := ListPages(c, appID, func(page []api.Domain) error {
+		domains = append(domains, page...)
 
-	if status != 200 {
-		return []api.Domain{}, errors.New(body)
-	}
+		if limit > 0 && len(domains) >= limit {
+			domains = domains[:limit]
+			return client.ErrStopPaging
+		}
 
-	domains := api.Domains{}
-	if err = json.Unmarshal([]byte(body), &domains); err != nil {
+		return nil
+	})
+
+	if err != nil {
 		return []api.Domain{}, err
 	}
 
-	return domains.Domains, nil
+	return domains, nil
+}
+
+// ListPages calls handle with each page of domains registered with an app. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, appID string, handle func([]api.Domain) error) error {
+	return c.ListPages(fmt.Sprintf("/v1/apps/%s/domains/", appID), func(results []byte) error {
+		page := []api.Domain{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+
+		return handle(page)
+	})
 }
 
 // New adds a domain to an app.
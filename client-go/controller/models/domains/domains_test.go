@@ -110,7 +110,7 @@ func TestDomainsList(t *testing.T) {
 
 	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
 
-	actual, err := List(&client, "example-go")
+	actual, err := List(&client, "example-go", -1)
 
 	if err != nil {
 		t.Fatal(err)
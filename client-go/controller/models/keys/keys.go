@@ -9,24 +9,40 @@ import (
 	"github.com/deis/deis/client-go/controller/client"
 )
 
-// List keys on a controller.
-func List(c *client.Client) ([]api.Key, error) {
-	body, status, err := c.BasicRequest("GET", "/v1/keys/", nil)
+// List keys on a controller, following pagination. If limit is positive, at most
+// limit keys are returned.
+func List(c *client.Client, limit int) ([]api.Key, error) {
+	keys := []api.Key{}
 
-	if err != nil {
-		return []api.Key{}, err
-	}
+	err := ListPages(c, func(page []api.Key) error {
+		keys = append(keys, page...)
 
-	if status != 200 {
-		return []api.Key{}, errors.New(body)
-	}
+		if limit > 0 && len(keys) >= limit {
+			keys = keys[:limit]
+			return client.ErrStopPaging
+		}
 
-	keys := api.Keys{}
-	if err = json.Unmarshal([]byte(body), &keys); err != nil {
+		return nil
+	})
+
+	if err != nil {
 		return []api.Key{}, err
 	}
 
-	return keys.Keys, nil
+	return keys, nil
+}
+
+// ListPages calls handle with each page of keys on a controller. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, handle func([]api.Key) error) error {
+	return c.ListPages("/v1/keys/", func(results []byte) error {
+		page := []api.Key{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+
+		return handle(page)
+	})
 }
 
 // New creates a new key.
@@ -113,7 +113,7 @@ func TestKeysList(t *testing.T) {
 
 	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
 
-	actual, err := List(&client)
+	actual, err := List(&client, -1)
 
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,112 @@
+package perms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/deis/deis/client-go/controller/api"
+	"github.com/deis/deis/client-go/controller/client"
+)
+
+// List users that can access an app.
+func List(c *client.Client, appID string) ([]string, error) {
+	u := fmt.Sprintf("/v1/apps/%s/perms/", appID)
+
+	body, status, err := c.BasicRequest("GET", u, nil)
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	if status != 200 {
+		return []string{}, errors.New(body)
+	}
+
+	users := api.PermsAppResponse{}
+	if err = json.Unmarshal([]byte(body), &users); err != nil {
+		return []string{}, err
+	}
+
+	return users.Users, nil
+}
+
+// ListAdmins lists users with system administrator privileges, following pagination.
+func ListAdmins(c *client.Client) ([]string, error) {
+	usernames := []string{}
+
+	err := c.ListPages("/v1/admin/perms/", func(results []byte) error {
+		admins := []api.PermsAdmin{}
+		if err := json.Unmarshal(results, &admins); err != nil {
+			return err
+		}
+
+		for _, user := range admins {
+			usernames = append(usernames, user.Username)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	return usernames, nil
+}
+
+// New gives a user access to an app.
+func New(c *client.Client, appID string, username string) error {
+	return doNew(c, fmt.Sprintf("/v1/apps/%s/perms/", appID), username)
+}
+
+// NewAdmin gives a user system administrator privileges.
+func NewAdmin(c *client.Client, username string) error {
+	return doNew(c, "/v1/admin/perms/", username)
+}
+
+func doNew(c *client.Client, u string, username string) error {
+	req := api.PermsRequest{Username: username}
+
+	reqBody, err := json.Marshal(req)
+
+	if err != nil {
+		return err
+	}
+
+	body, status, err := c.BasicRequest("POST", u, reqBody)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 201 {
+		return errors.New(body)
+	}
+
+	return nil
+}
+
+// Delete removes a user's access to an app.
+func Delete(c *client.Client, appID string, username string) error {
+	return doDelete(c, fmt.Sprintf("/v1/apps/%s/perms/%s", appID, username))
+}
+
+// DeleteAdmin removes a user's system administrator privileges.
+func DeleteAdmin(c *client.Client, username string) error {
+	return doDelete(c, fmt.Sprintf("/v1/admin/perms/%s", username))
+}
+
+func doDelete(c *client.Client, u string) error {
+	body, status, err := c.BasicRequest("DELETE", u, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != 204 {
+		return errors.New(body)
+	}
+
+	return nil
+}
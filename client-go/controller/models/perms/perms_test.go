@@ -0,0 +1,202 @@
+package perms
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/deis/deis/client-go/controller/client"
+	"github.com/deis/deis/version"
+)
+
+const adminFixture string = `
+{
+    "count": 1,
+    "next": null,
+    "previous": null,
+    "results": [
+        {
+            "username": "test",
+            "is_superuser": true
+        },
+        {
+            "username": "foo",
+            "is_superuser": true
+        }
+    ]
+}`
+
+const appUsersFixture string = `
+{
+    "users": [
+        "test",
+        "foo"
+    ]
+}`
+
+const createExpected string = `{"username":"test"}`
+
+type fakeHTTPServer struct{}
+
+func (fakeHTTPServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Add("DEIS_API_VERSION", version.APIVersion)
+
+	if req.URL.Path == "/v1/apps/foo/perms/" && req.Method == "GET" {
+		res.Write([]byte(appUsersFixture))
+		return
+	}
+
+	if req.URL.Path == "/v1/admin/perms/" && req.Method == "GET" {
+		res.Write([]byte(adminFixture))
+		return
+	}
+
+	if (req.URL.Path == "/v1/apps/foo/perms/" || req.URL.Path == "/v1/admin/perms/") &&
+		req.Method == "POST" {
+		body, err := ioutil.ReadAll(req.Body)
+
+		if err != nil {
+			fmt.Println(err)
+			res.WriteHeader(http.StatusInternalServerError)
+			res.Write(nil)
+		}
+
+		if string(body) != createExpected {
+			fmt.Printf("Expected '%s', Got '%s'\n", createExpected, body)
+			res.WriteHeader(http.StatusInternalServerError)
+			res.Write(nil)
+			return
+		}
+
+		res.WriteHeader(http.StatusCreated)
+		res.Write(nil)
+		return
+	}
+
+	if (req.URL.Path == "/v1/apps/foo/perms/test" || req.URL.Path == "/v1/admin/perms/test") &&
+		req.Method == "DELETE" {
+		res.WriteHeader(http.StatusNoContent)
+		res.Write(nil)
+		return
+	}
+
+	fmt.Printf("Unrecognized URL %s\n", req.URL)
+	res.WriteHeader(http.StatusNotFound)
+	res.Write(nil)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	expected := []string{"test", "foo"}
+
+	handler := fakeHTTPServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := client.CreateHTTPClient(false)
+
+	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
+
+	actual, err := List(&client, "foo")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, Got %v", expected, actual)
+	}
+}
+
+func TestListAdmins(t *testing.T) {
+	t.Parallel()
+
+	expected := []string{"test", "foo"}
+
+	handler := fakeHTTPServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := client.CreateHTTPClient(false)
+
+	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
+
+	actual, err := ListAdmins(&client)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, Got %v", expected, actual)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	handler := fakeHTTPServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := client.CreateHTTPClient(false)
+
+	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
+
+	if err = New(&client, "foo", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = NewAdmin(&client, "test"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	handler := fakeHTTPServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := client.CreateHTTPClient(false)
+
+	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
+
+	if err = Delete(&client, "foo", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = DeleteAdmin(&client, "test"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -10,25 +10,33 @@ import (
 	"github.com/deis/deis/client-go/controller/client"
 )
 
-// List an app's processes.
+// List an app's processes, following pagination.
 func List(c *client.Client, appID string) ([]api.Process, error) {
-	u := fmt.Sprintf("/v1/apps/%s/containers/", appID)
-	body, status, err := c.BasicRequest("GET", u, nil)
+	procs := []api.Process{}
+
+	err := ListPages(c, appID, func(page []api.Process) error {
+		procs = append(procs, page...)
+		return nil
+	})
 
 	if err != nil {
 		return []api.Process{}, err
 	}
 
-	if status != 200 {
-		return []api.Process{}, errors.New(body)
-	}
+	return procs, nil
+}
 
-	procs := api.Processes{}
-	if err = json.Unmarshal([]byte(body), &procs); err != nil {
-		return []api.Process{}, err
-	}
+// ListPages calls handle with each page of an app's processes. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, appID string, handle func([]api.Process) error) error {
+	return c.ListPages(fmt.Sprintf("/v1/apps/%s/containers/", appID), func(results []byte) error {
+		page := []api.Process{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
 
-	return procs.Processes, nil
+		return handle(page)
+	})
 }
 
 // Scale an app's processes.
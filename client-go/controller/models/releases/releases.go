@@ -0,0 +1,103 @@
+package releases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/deis/deis/client-go/controller/api"
+	"github.com/deis/deis/client-go/controller/client"
+)
+
+// List lists an app's releases, following pagination. If limit is positive, at most
+// limit releases are returned.
+func List(c *client.Client, appID string, limit int) ([]api.Release, error) {
+	releases := []api.Release{}
+
+	err := ListPages(c, appID, func(page []api.Release) error {
+		releases = append(releases, page...)
+
+		if limit > 0 && len(releases) >= limit {
+			releases = releases[:limit]
+			return client.ErrStopPaging
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return []api.Release{}, err
+	}
+
+	return releases, nil
+}
+
+// ListPages calls handle with each page of an app's releases. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, appID string, handle func([]api.Release) error) error {
+	return c.ListPages(fmt.Sprintf("/v1/apps/%s/releases/", appID), func(results []byte) error {
+		page := []api.Release{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
+
+		return handle(page)
+	})
+}
+
+// Get a release of an app.
+func Get(c *client.Client, appID string, version int) (api.Release, error) {
+	u := fmt.Sprintf("/v1/apps/%s/releases/v%d/", appID, version)
+
+	body, status, err := c.BasicRequest("GET", u, nil)
+
+	if err != nil {
+		return api.Release{}, err
+	}
+
+	if status != 200 {
+		return api.Release{}, errors.New(body)
+	}
+
+	release := api.Release{}
+	if err = json.Unmarshal([]byte(body), &release); err != nil {
+		return api.Release{}, err
+	}
+
+	return release, nil
+}
+
+// Rollback rolls an app back to a previous release. If version is -1, the app
+// is rolled back one release. It returns the version of the new release.
+func Rollback(c *client.Client, appID string, version int) (int, error) {
+	u := fmt.Sprintf("/v1/apps/%s/releases/rollback/", appID)
+
+	req := api.ReleaseRollback{}
+
+	if version != -1 {
+		req.Version = version
+	}
+
+	body, err := json.Marshal(req)
+
+	if err != nil {
+		return -1, err
+	}
+
+	resBody, status, err := c.BasicRequest("POST", u, body)
+
+	if err != nil {
+		return -1, err
+	}
+
+	if status != 201 {
+		return -1, errors.New(resBody)
+	}
+
+	res := api.ReleaseRollback{}
+	if err = json.Unmarshal([]byte(resBody), &res); err != nil {
+		return -1, err
+	}
+
+	return res.Version, nil
+}
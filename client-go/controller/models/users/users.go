@@ -2,28 +2,43 @@ package users
 
 import (
 	"encoding/json"
-	"errors"
 
 	"github.com/deis/deis/client-go/controller/api"
 	"github.com/deis/deis/client-go/controller/client"
 )
 
-// List users registered with the controller.
-func List(c *client.Client) ([]api.User, error) {
-	body, status, err := c.BasicRequest("GET", "/v1/users/", nil)
+// List users registered with the controller, following pagination. If limit is positive, at most
+// limit users are returned.
+func List(c *client.Client, limit int) ([]api.User, error) {
+	users := []api.User{}
+
+	err := ListPages(c, func(page []api.User) error {
+		users = append(users, page...)
+
+		if limit > 0 && len(users) >= limit {
+			users = users[:limit]
+			return client.ErrStopPaging
+		}
+
+		return nil
+	})
 
 	if err != nil {
 		return []api.User{}, err
 	}
 
-	if status != 200 {
-		return []api.User{}, errors.New(body)
-	}
+	return users, nil
+}
 
-	users := api.Users{}
-	if err = json.Unmarshal([]byte(body), &users); err != nil {
-		return []api.User{}, err
-	}
+// ListPages calls handle with each page of users registered with the controller. Returning
+// client.ErrStopPaging from handle stops paging early.
+func ListPages(c *client.Client, handle func([]api.User) error) error {
+	return c.ListPages("/v1/users/", func(results []byte) error {
+		page := []api.User{}
+		if err := json.Unmarshal(results, &page); err != nil {
+			return err
+		}
 
-	return users.Users, nil
+		return handle(page)
+	})
 }
@@ -83,7 +83,7 @@ func TestUsersList(t *testing.T) {
 
 	client := client.Client{HTTPClient: httpClient, ControllerURL: *u, Token: "abc"}
 
-	actual, err := List(&client)
+	actual, err := List(&client, -1)
 
 	if err != nil {
 		t.Fatal(err)
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/deis/deis/client-go/cmd"
 	"github.com/deis/deis/client-go/parser"
 	"github.com/deis/deis/version"
 	docopt "github.com/docopt/docopt-go"
@@ -45,6 +46,7 @@ Subcommands, use 'deis help [subcommand]' to learn more::
   perms         manage permissions for applications
   git           manage git for applications
   users         manage users
+  profiles      manage profiles for multiple controllers
 
 Shortcut commands, use 'deis shortcuts' to see all::
 
@@ -57,8 +59,27 @@ Shortcut commands, use 'deis shortcuts' to see all::
   destroy       destroy an application
   pull          imports an image and deploys as a new release
 
+Output options, for listing and info commands::
+
+  -o --output=<format>  render results as table (the default), json or yaml.
+                        config:pull takes -o as --overwrite, so pass -o
+                        before the command there.
+  --format=<template>   render each result with a Go template, e.g. '{{.ID}}'.
+
 Use 'git push deis master' to deploy to an application.
 `
+	argv, output, format, err := parseOutputFlags(argv)
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	if err = cmd.SetOutput(output, format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
 	// Reorganize some command line flags and commands.
 	argv = parseArgs(argv)
 	// Give docopt an optional final false arg so it doesn't call os.Exit().
@@ -89,14 +110,24 @@ Use 'git push deis master' to deploy to an application.
 		err = parser.Domains(argv)
 	case "builds":
 		err = parser.Builds(argv)
+	case "limits":
+		err = parser.Limits(argv)
+	case "releases":
+		err = parser.Releases(argv)
 	case "tags":
 		err = parser.Tags(argv)
+	case "certs":
+		err = parser.Certs(argv)
 	case "keys":
 		err = parser.Keys(argv)
+	case "perms":
+		err = parser.Perms(argv)
 	case "git":
 		err = parser.Git(argv)
 	case "users":
 		err = parser.Users(argv)
+	case "profiles":
+		err = parser.Profiles(argv)
 	case "help":
 		fmt.Print(usage)
 		return 0
@@ -144,6 +175,63 @@ func parseArgs(argv []string) []string {
 	return argv
 }
 
+// shortOutputCommands are the commands whose own usage defines -o, so it is left to them
+// when it comes after the command.
+var shortOutputCommands = map[string]bool{
+	"config:pull": true,
+}
+
+// parseOutputFlags removes the output flags from argv and returns them. They may appear
+// anywhere, except -o after a command in shortOutputCommands.
+func parseOutputFlags(argv []string) ([]string, string, string, error) {
+	var output, format, command string
+	remaining := []string{}
+
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+
+		// Leave everything after "--" alone, as well as the arguments to run, they belong to
+		// the command being run.
+		if arg == "--" || (len(remaining) == 0 && (arg == "run" || arg == "apps:run")) {
+			remaining = append(remaining, argv[i:]...)
+			break
+		}
+
+		var target *string
+		var value string
+		hasValue := false
+
+		switch {
+		case arg == "--output" || (arg == "-o" && !shortOutputCommands[replaceShortcut(command)]):
+			target = &output
+		case arg == "--format":
+			target = &format
+		case strings.HasPrefix(arg, "--output="):
+			target, value, hasValue = &output, strings.TrimPrefix(arg, "--output="), true
+		case strings.HasPrefix(arg, "--format="):
+			target, value, hasValue = &format, strings.TrimPrefix(arg, "--format="), true
+		default:
+			if len(remaining) == 0 {
+				command = arg
+			}
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(argv) {
+				return nil, "", "", fmt.Errorf("%s requires an argument", arg)
+			}
+			i++
+			value = argv[i]
+		}
+
+		*target = value
+	}
+
+	return remaining, output, format, nil
+}
+
 func replaceShortcut(command string) string {
 	shortcuts := map[string]string{
 		"create":         "apps:create",
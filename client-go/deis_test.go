@@ -67,3 +67,49 @@ func TestReplaceShortcutUnchanged(t *testing.T) {
 		t.Errorf("Expected %s, Got %s", expected, actual)
 	}
 }
+
+func TestParseOutputFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		argv     []string
+		expected []string
+		output   string
+		format   string
+	}{
+		{[]string{"apps:list"}, []string{"apps:list"}, "", ""},
+		{[]string{"-o", "json", "apps:list"}, []string{"apps:list"}, "json", ""},
+		{[]string{"apps:list", "--output", "yaml"}, []string{"apps:list"}, "yaml", ""},
+		{[]string{"ps:list", "--output=json", "-a", "foo"}, []string{"ps:list", "-a", "foo"}, "json", ""},
+		{[]string{"apps:list", "--format={{.ID}}"}, []string{"apps:list"}, "", "{{.ID}}"},
+		{[]string{"apps:list", "-o", "json"}, []string{"apps:list"}, "json", ""},
+		{[]string{"ps:list", "-a", "foo", "-o", "yaml"}, []string{"ps:list", "-a", "foo"}, "yaml", ""},
+		{[]string{"config:pull", "-o"}, []string{"config:pull", "-o"}, "", ""},
+		{[]string{"-o", "json", "config:pull", "-o"}, []string{"config:pull", "-o"}, "json", ""},
+		{[]string{"run", "ls", "--format=x"}, []string{"run", "ls", "--format=x"}, "", ""},
+	}
+
+	for _, test := range tests {
+		actual, output, format, err := parseOutputFlags(test.argv)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("Expected %v, Got %v", test.expected, actual)
+		}
+
+		if output != test.output {
+			t.Errorf("Expected output %s, Got %s", test.output, output)
+		}
+
+		if format != test.format {
+			t.Errorf("Expected format %s, Got %s", test.format, format)
+		}
+	}
+
+	if _, _, _, err := parseOutputFlags([]string{"apps:list", "--output"}); err == nil {
+		t.Error("Expected an error when --output has no argument")
+	}
+}
@@ -91,13 +91,25 @@ func appsList(argv []string) error {
 	usage := `
 Lists applications visible to the current user.
 
-Usage: deis apps:list
+Usage: deis apps:list [options]
+
+Options:
+  -l --limit=<num>
+    the maximum number of results to display.
 `
-	if _, err := docopt.Parse(usage, argv, true, "", false, true); err != nil {
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
 		return err
 	}
 
-	return cmd.AppsList()
+	results, err := responseLimit(safeGetValue(args, "--limit"))
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.AppsList(results)
 }
 
 func appInfo(argv []string) error {
@@ -153,6 +165,21 @@ Options:
     the uniquely identifiable name for the application.
   -n --lines=<lines>
     the number of lines to display
+  -f --follow
+    keep the connection open and print new log events as they arrive.
+  --ps=<type>
+    only show log events from a process type, such as 'web', or a single process,
+    such as 'web.1'.
+  --source=<source>
+    only show log events from 'deis' (platform events) or 'app' (application output).
+  --since=<time>
+    only show log events logged at or after a time, such as '2015-06-01T12:00:00Z',
+    or a duration, such as '1h' for an hour ago. Not supported with --follow.
+  --until=<time>
+    only show log events logged at or before a time or duration ago.
+    Not supported with --follow.
+  --grep=<pattern>
+    only show log events matching a regular expression. Not supported with --follow.
 `
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
 
@@ -175,7 +202,23 @@ Options:
 		}
 	}
 
-	return cmd.AppLogs(app, lines)
+	follow := args["--follow"].(bool)
+	ps := safeGetValue(args, "--ps")
+	source := safeGetValue(args, "--source")
+
+	if source != "" && source != "deis" && source != "app" {
+		return fmt.Errorf("%s is not a valid source, must be 'deis' or 'app'", source)
+	}
+
+	since := safeGetValue(args, "--since")
+	until := safeGetValue(args, "--until")
+	grep := safeGetValue(args, "--grep")
+
+	if follow && (since != "" || until != "" || grep != "") {
+		return fmt.Errorf("--since, --until and --grep cannot be used with --follow")
+	}
+
+	return cmd.AppLogs(app, lines, follow, ps, source, since, until, grep)
 }
 
 func appRun(argv []string) error {
@@ -183,6 +226,10 @@ func appRun(argv []string) error {
 Runs a command inside an ephemeral app container. Default environment is
 /bin/bash.
 
+By default the command's output is printed once it finishes. With --interactive,
+stdin, stdout and stderr are attached to the command while it runs, and with
+--tty it runs in a terminal, so shells and consoles can be used.
+
 Usage: deis apps:run [options] [--] <command>...
 
 Arguments:
@@ -192,6 +239,10 @@ Arguments:
 Options:
   -a --app=<app>
     the uniquely identifiable name for the application.
+  -i --interactive
+    attach stdin, stdout and stderr to the command while it runs.
+  -t --tty
+    run the command in a terminal. Implies --interactive.
 `
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
 
@@ -201,8 +252,10 @@ Options:
 
 	app := safeGetValue(args, "--app")
 	command := strings.Join(args["<command>"].([]string), " ")
+	interactive := args["--interactive"].(bool)
+	tty := args["--tty"].(bool)
 
-	return cmd.AppRun(app, command)
+	return cmd.AppRun(app, command, interactive, tty)
 }
 
 func appDestroy(argv []string) error {
@@ -106,6 +106,9 @@ Options:
     provide a password for the account.
   --ssl-verify=false
     disables SSL certificate verification for API requests
+  --profile=<profile>
+    save the session to a named profile instead of the one set with DEIS_PROFILE
+    or 'deis profiles:use'.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -117,13 +120,14 @@ Options:
 	controller := safeGetValue(args, "<controller>")
 	username := safeGetValue(args, "--username")
 	password := safeGetValue(args, "--password")
+	profile := safeGetValue(args, "--profile")
 	sslVerify := false
 
 	if args["--ssl-verify"] != nil && args["--ssl-verify"].(string) == "true" {
 		sslVerify = true
 	}
 
-	return cmd.Login(controller, username, password, sslVerify)
+	return cmd.Login(controller, username, password, sslVerify, profile)
 }
 
 func authLogout(argv []string) error {
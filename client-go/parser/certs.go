@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/deis/deis/client-go/cmd"
+	docopt "github.com/docopt/docopt-go"
+)
+
+// Certs routes certs commands to their specific function.
+func Certs(argv []string) error {
+	usage := `
+Valid commands for certs:
+
+certs:list            list SSL certificates for an app
+certs:add             add an SSL certificate to an app
+certs:info            view info about an SSL certificate
+certs:remove          remove an SSL certificate from an app
+
+Use 'deis help [command]' to learn more.
+`
+	if len(argv) < 2 {
+		return certsList([]string{"certs:list"})
+	}
+
+	switch argv[1] {
+	case "list":
+		return certsList(combineCommand(argv))
+	case "add":
+		return certAdd(combineCommand(argv))
+	case "info":
+		return certInfo(combineCommand(argv))
+	case "remove":
+		return certRemove(combineCommand(argv))
+	case "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		PrintUsage()
+		return nil
+	}
+}
+
+func certsList(argv []string) error {
+	usage := `
+Show certificate information for an SSL application.
+
+Usage: deis certs:list [options]
+
+Options:
+  -l --limit=<num>
+    the maximum number of results to display.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	results, err := responseLimit(safeGetValue(args, "--limit"))
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.CertsList(results)
+}
+
+func certAdd(argv []string) error {
+	usage := `
+Binds a certificate/key pair to an application.
+
+The certificate and key are checked locally before they are uploaded. The upload is
+refused if the key does not match the certificate, and a warning is printed if the
+certificate has expired.
+
+Usage: deis certs:add <cert> <key> [options]
+
+Arguments:
+  <cert>
+    The public key of the SSL certificate.
+  <key>
+    The private key of the SSL certificate.
+
+Options:
+  --common-name=<cname>
+    The common name of the certificate. If none is provided, the controller will
+    interpret the common name from the certificate.
+  --subject-alt-names=<sans>
+    The subject alternate names (SAN) of the certificate, separated by commas. This will
+    create multiple Certificate objects in the controller, one for each SAN.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	cert := safeGetValue(args, "<cert>")
+	key := safeGetValue(args, "<key>")
+	commonName := safeGetValue(args, "--common-name")
+	sans := safeGetValue(args, "--subject-alt-names")
+
+	return cmd.CertAdd(cert, key, commonName, sans)
+}
+
+func certInfo(argv []string) error {
+	usage := `
+Prints info about a certificate.
+
+Usage: deis certs:info <common-name>
+
+Arguments:
+  <common-name>
+    the common name of the cert.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.CertInfo(safeGetValue(args, "<common-name>"))
+}
+
+func certRemove(argv []string) error {
+	usage := `
+Removes a certificate/key pair from the application.
+
+Usage: deis certs:remove <common-name>
+
+Arguments:
+  <common-name>
+    the common name of the cert to remove from the app.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.CertRemove(safeGetValue(args, "<common-name>"))
+}
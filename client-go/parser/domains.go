@@ -71,6 +71,8 @@ Usage: deis domains:list [options]
 Options:
 	-a --app=<app>
 		the uniquely identifiable name for the application.
+	-l --limit=<num>
+		the maximum number of results to display.
 `
 
 	args, err := docopt.Parse(usage, argv, true, "", false, true)
@@ -79,7 +81,13 @@ Options:
 		return err
 	}
 
-	return cmd.DomainsList(safeGetValue(args, "--app"))
+	results, err := responseLimit(safeGetValue(args, "--limit"))
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.DomainsList(safeGetValue(args, "--app"), results)
 }
 
 func domainsRemove(argv []string) error {
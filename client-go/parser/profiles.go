@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/deis/deis/client-go/cmd"
+	docopt "github.com/docopt/docopt-go"
+)
+
+// Profiles routes profile commands to the specific function.
+func Profiles(argv []string) error {
+	usage := `
+Valid commands for profiles:
+
+profiles:list        list the profiles for controllers you have logged in to
+profiles:use         set the default profile
+profiles:remove      remove a profile
+
+Use 'deis help [command]' to learn more.
+`
+	if len(argv) < 2 {
+		return profilesList([]string{"profiles:list"})
+	}
+
+	switch argv[1] {
+	case "list":
+		return profilesList(combineCommand(argv))
+	case "use":
+		return profileUse(combineCommand(argv))
+	case "remove":
+		return profileRemove(combineCommand(argv))
+	case "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		PrintUsage()
+		return nil
+	}
+}
+
+func profilesList(argv []string) error {
+	usage := `
+Lists the profiles for controllers you have logged in to. The active profile is
+marked with a '*'.
+
+The active profile is chosen from, in order: the DEIS_PROFILE environment
+variable, a profile whose controller matches a git remote of the current
+repository, and the default set with 'deis profiles:use'. 'deis login' never
+saves to a profile matched by a git remote.
+
+Usage: deis profiles:list
+`
+
+	if _, err := docopt.Parse(usage, argv, true, "", false, true); err != nil {
+		return err
+	}
+
+	return cmd.ProfilesList()
+}
+
+func profileUse(argv []string) error {
+	usage := `
+Sets the default profile.
+
+Usage: deis profiles:use <profile>
+
+Arguments:
+  <profile>
+    the name of the profile, as given to 'deis login --profile'.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.ProfileUse(safeGetValue(args, "<profile>"))
+}
+
+func profileRemove(argv []string) error {
+	usage := `
+Removes a profile and the session stored in it.
+
+Usage: deis profiles:remove <profile>
+
+Arguments:
+  <profile>
+    the name of the profile to remove.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.ProfileRemove(safeGetValue(args, "<profile>"))
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deis/deis/client-go/cmd"
+	docopt "github.com/docopt/docopt-go"
+)
+
+// Releases routes releases commands to their specific function.
+func Releases(argv []string) error {
+	usage := `
+Valid commands for releases:
+
+releases:list        list an application's release history
+releases:info        print information about a specific release
+releases:rollback    return to a previous release
+
+Use 'deis help [command]' to learn more.
+`
+	if len(argv) < 2 {
+		return releasesList([]string{"releases:list"})
+	}
+
+	switch argv[1] {
+	case "list":
+		return releasesList(combineCommand(argv))
+	case "info":
+		return releasesInfo(combineCommand(argv))
+	case "rollback":
+		return releasesRollback(combineCommand(argv))
+	case "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		PrintUsage()
+		return nil
+	}
+}
+
+func releasesList(argv []string) error {
+	usage := `
+Lists release history for an application.
+
+Usage: deis releases:list [options]
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+  -l --limit=<num>
+    the maximum number of results to display.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	results, err := responseLimit(safeGetValue(args, "--limit"))
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.ReleasesList(safeGetValue(args, "--app"), results)
+}
+
+func releasesInfo(argv []string) error {
+	usage := `
+Prints info about a particular release.
+
+Usage: deis releases:info <version> [options]
+
+Arguments:
+  <version>
+    the release of the application, such as 'v1'.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name for the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	version, err := versionFromString(safeGetValue(args, "<version>"))
+
+	if err != nil {
+		return err
+	}
+
+	return cmd.ReleasesInfo(safeGetValue(args, "--app"), version)
+}
+
+func releasesRollback(argv []string) error {
+	usage := `
+Rolls back to a previous application release.
+
+Usage: deis releases:rollback [<version>] [options]
+
+Arguments:
+  <version>
+    the release of the application, such as 'v1'.
+
+Options:
+  -a --app=<app>
+    the uniquely identifiable name of the application.
+`
+
+	args, err := docopt.Parse(usage, argv, true, "", false, true)
+
+	if err != nil {
+		return err
+	}
+
+	versionStr := safeGetValue(args, "<version>")
+	version := -1
+
+	if versionStr != "" {
+		version, err = versionFromString(versionStr)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return cmd.ReleasesRollback(safeGetValue(args, "--app"), version)
+}
+
+// versionFromString converts a release version such as "v2" or "2" to an int.
+func versionFromString(version string) (int, error) {
+	num, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+
+	if err != nil {
+		return -1, fmt.Errorf("%s is not a valid release version, ex: v2", version)
+	}
+
+	return num, nil
+}
@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestVersionFromString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]int{"v1": 1, "2": 2, "v10": 10}
+
+	for test, expected := range tests {
+		actual, err := versionFromString(test)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected != actual {
+			t.Errorf("Expected %d, Got %d", expected, actual)
+		}
+	}
+}
+
+func TestVersionFromStringInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := versionFromString("vX"); err == nil {
+		t.Error("Expected an error for an invalid version")
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 )
 
 // docopt expects commands to be in the proper format, but we split them apart for
@@ -21,6 +22,21 @@ func safeGetValue(args map[string]interface{}, key string) string {
 	return args[key].(string)
 }
 
+// responseLimit parses the --limit option. An empty limit means no limit, which is -1.
+func responseLimit(limit string) (int, error) {
+	if limit == "" {
+		return -1, nil
+	}
+
+	num, err := strconv.Atoi(limit)
+
+	if err != nil || num < 1 {
+		return 0, fmt.Errorf("%s is not a valid limit. It must be a positive integer.", limit)
+	}
+
+	return num, nil
+}
+
 // PrintUsage runs if no matching command is found.
 func PrintUsage() {
 	fmt.Println("Found no matching command, try 'deis help'")
@@ -45,3 +45,30 @@ func TestSafeGetNil(t *testing.T) {
 		t.Errorf("Expected %s, Got %s", expected, actual)
 	}
 }
+
+func TestResponseLimit(t *testing.T) {
+	t.Parallel()
+
+	checks := map[string]int{
+		"":   -1,
+		"10": 10,
+	}
+
+	for limit, expected := range checks {
+		actual, err := responseLimit(limit)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != expected {
+			t.Errorf("Expected %d, Got %d", expected, actual)
+		}
+	}
+
+	for _, limit := range []string{"0", "-5", "ten"} {
+		if _, err := responseLimit(limit); err == nil {
+			t.Errorf("Expected an error for limit %s", limit)
+		}
+	}
+}
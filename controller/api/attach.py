@@ -0,0 +1,101 @@
+"""
+Relay an interactive one-off command between a hijacked client connection and the
+scheduler channel the command runs on.
+
+After the upgrade response, both directions carry frames with the same header as
+Docker's multiplexed attach stream: one byte for the stream type, three zero bytes and
+the payload length as a big-endian uint32.
+"""
+
+import errno
+import os
+import select
+import socket
+import struct
+
+STDIN, STDOUT, STDERR, RESIZE, EXIT = range(5)
+
+HEADER = struct.Struct('>B3xI')
+RESIZE_PAYLOAD = struct.Struct('>HH')
+EXIT_PAYLOAD = struct.Struct('>i')
+
+UPGRADE_RESPONSE = (b'HTTP/1.1 101 UPGRADED\r\n'
+                    b'Content-Type: application/vnd.deis.raw-stream\r\n'
+                    b'Connection: Upgrade\r\n'
+                    b'Upgrade: tcp\r\n'
+                    b'\r\n')
+
+
+def encode_frame(stream, payload):
+    """Prefix a payload with the frame header for its stream."""
+    return HEADER.pack(stream, len(payload)) + payload
+
+
+class FrameReader(object):
+    """Decode frames from a byte stream that may split them at any point."""
+
+    def __init__(self):
+        self.buf = b''
+
+    def feed(self, data):
+        """Buffer data and return a list of (stream, payload) tuples for every whole frame."""
+        self.buf += data
+        frames = []
+        while len(self.buf) >= HEADER.size:
+            stream, length = HEADER.unpack(self.buf[:HEADER.size])
+            end = HEADER.size + length
+            if len(self.buf) < end:
+                break
+            frames.append((stream, self.buf[HEADER.size:end]))
+            self.buf = self.buf[end:]
+        return frames
+
+
+def relay(sock, chan, bufsize=4096, interval=0.05):
+    """
+    Relay frames between a hijacked client socket and a scheduler channel.
+
+    An empty stdin frame closes the command's stdin. Returns the command's exit code, or
+    None if the client went away before the command exited.
+    """
+    sock.sendall(UPGRADE_RESPONSE)
+    reader = FrameReader()
+    try:
+        while True:
+            readable, _, _ = select.select([sock], [], [], interval)
+            if readable:
+                data = sock.recv(bufsize)
+                if not data:
+                    chan.close()
+                    return None
+                for stream, payload in reader.feed(data):
+                    if stream == STDIN and payload:
+                        chan.sendall(payload)
+                    elif stream == STDIN:
+                        chan.shutdown_write()
+                    elif stream == RESIZE:
+                        rows, cols = RESIZE_PAYLOAD.unpack(payload)
+                        chan.resize_pty(width=cols, height=rows)
+            while chan.recv_ready():
+                sock.sendall(encode_frame(STDOUT, chan.recv(bufsize)))
+            while chan.recv_stderr_ready():
+                sock.sendall(encode_frame(STDERR, chan.recv_stderr(bufsize)))
+            if chan.exit_status_ready() and not chan.recv_ready() and \
+               not chan.recv_stderr_ready():
+                rc = chan.recv_exit_status()
+                sock.sendall(encode_frame(EXIT, EXIT_PAYLOAD.pack(rc)))
+                return rc
+    except socket.error:
+        chan.close()
+        return None
+
+
+def hijacked_body():
+    """
+    Stand in for the body of the response to a request whose connection ``relay`` took over
+    and then closed. A response written to that connection would fail with a broken pipe, so
+    this fails the same way before anything is written, which gunicorn takes as the client
+    having gone away: it closes the connection without writing a response or logging an error.
+    """
+    raise socket.error(errno.EPIPE, os.strerror(errno.EPIPE))
+    yield
@@ -6,13 +6,15 @@ Data models for the Deis API.
 
 from __future__ import unicode_literals
 import base64
+import collections
 from datetime import datetime
 import etcd
+import glob
+import gzip
 import importlib
 import logging
 import os
 import re
-import subprocess
 import time
 from threading import Thread
 
@@ -38,6 +40,18 @@ from utils import dict_diff, fingerprint
 
 logger = logging.getLogger(__name__)
 
+# matches the "<tag>[<pid>]: " prefix of a line in an application's log file
+LOG_LINE_MATCH = re.compile(r'^\S+ (?P<tag>[-a-z0-9]+)\[(?P<pid>[-_.\w]+)\]: ')
+
+# deis-logger stores the newlines within a log event, such as a stack trace, as U+2028 (in
+# UTF-8) so the event is one line of the log file
+LOG_LINE_SEPARATOR = b'\xe2\x80\xa8'
+
+
+def unescape_log_lines(data):
+    """Turn the line separators within log events back into newlines."""
+    return data.replace(LOG_LINE_SEPARATOR, b'\n')
+
 
 def close_db_connections(func, *args, **kwargs):
     """
@@ -58,6 +72,43 @@ def close_db_connections(func, *args, **kwargs):
     return _close_db_connections
 
 
+def log_line_matches(line, ps=None, source=None):
+    """
+    Return whether a line from an application's log file matches the given filters.
+
+    ``ps`` matches a process type such as "web" or a single process such as "web.1".
+    ``source`` is either "deis" for platform events or "app" for application output.
+    """
+    if not ps and not source:
+        return True
+    match = LOG_LINE_MATCH.match(line)
+    if not match:
+        return False
+    tag, pid = match.group('tag'), match.group('pid')
+    if source == 'deis' and tag != 'deis':
+        return False
+    if source == 'app' and tag == 'deis':
+        return False
+    if ps and pid != ps and not pid.startswith(ps + '.'):
+        return False
+    return True
+
+
+def _logger_api():
+    """
+    Return the URL and token of the deis-logger query API, or None if it is not published.
+    """
+    if not _etcd_client:
+        return None
+    try:
+        host = _etcd_client.get('/deis/logs/host').value
+        port = _etcd_client.get('/deis/logs/apiPort').value
+        token = _etcd_client.get('/deis/logs/apiToken').value
+    except (KeyError, etcd.EtcdException):
+        return None
+    return 'http://{}:{}'.format(host, port), token
+
+
 def log_event(app, msg, level=logging.INFO):
     # controller needs to know which app this log comes from
     logger.log(level, "{}: {}".format(app.id, msg))
@@ -179,6 +230,10 @@ class App(UuidAuditedModel):
     def url(self):
         return self.id + '.' + settings.DEIS_DOMAIN
 
+    @property
+    def log_path(self):
+        return os.path.join(settings.DEIS_LOG_DIR, self.id + '.log')
+
     def log(self, message):
         """Logs a message to the application's log file.
 
@@ -187,7 +242,7 @@ class App(UuidAuditedModel):
         Django's case because logging is set up before you run the server and it disables all
         existing logging configurations.
         """
-        with open(os.path.join(settings.DEIS_LOG_DIR, self.id + '.log'), 'a') as f:
+        with open(self.log_path, 'a') as f:
             msg = "{} deis[api]: {}\n".format(time.strftime(settings.DEIS_DATETIME_FORMAT),
                                               message)
             f.write(msg.encode('utf-8'))
@@ -217,10 +272,10 @@ class App(UuidAuditedModel):
         return to_restart
 
     def _clean_app_logs(self):
-        """Delete application logs stored by the logger component"""
-        path = os.path.join(settings.DEIS_LOG_DIR, self.id + '.log')
-        if os.path.exists(path):
-            os.remove(path)
+        """Delete application logs and rotated segments stored by the logger component"""
+        for path in [self.log_path] + glob.glob(self.log_path + '.*'):
+            if os.path.exists(path):
+                os.remove(path)
 
     def scale(self, user, structure):  # noqa
         """Scale containers up or down to match requested structure."""
@@ -369,23 +424,161 @@ class App(UuidAuditedModel):
 
         self.scale(user, structure)
 
-    def logs(self, log_lines):
-        """Return aggregated log data for this application."""
-        path = os.path.join(settings.DEIS_LOG_DIR, self.id + '.log')
-        if not os.path.exists(path):
+    def has_logs(self):
+        """Return whether this application has a log file or rotated segments of one."""
+        return os.path.exists(self.log_path) or bool(self._log_segments())
+
+    def _log_segments(self):
+        """Return the rotated segments of this application's log, oldest first."""
+        return sorted(glob.glob(self.log_path + '.*'))
+
+    def _log_history(self, log_lines, ps=None, source=None, current=None):
+        """
+        Return the last ``log_lines`` matching lines of ``current``, the open log file if there
+        is one, preceded by those of the newest rotated segment when it has too few, so lines
+        rotated out moments ago are not lost.
+        """
+        log_lines = int(log_lines)
+        lines = collections.deque(maxlen=log_lines)
+        if current is not None:
+            lines.extend(line for line in current if log_line_matches(line, ps, source))
+        segments = self._log_segments()
+        if len(lines) < log_lines and segments:
+            segment = segments[-1]
+            try:
+                with (gzip.open if segment.endswith('.gz') else open)(segment, 'rb') as f:
+                    older = collections.deque(
+                        (line for line in f if log_line_matches(line, ps, source)),
+                        maxlen=log_lines - len(lines))
+            except EnvironmentError:
+                # compressed or expired meanwhile
+                older = []
+            lines.extendleft(reversed(older))
+        return lines
+
+    def _open_log(self):
+        """Open the current log file, or return None if it has yet to be written after a
+        rotation."""
+        try:
+            return open(self.log_path, 'rb')
+        except EnvironmentError:
+            return None
+
+    def logs(self, log_lines, ps=None, source=None, since=None, until=None, grep=None):
+        """
+        Return aggregated log data for this application.
+
+        The logs are queried from deis-logger if its query API is published, which filtering by
+        ``since``, ``until`` and ``grep`` requires. Otherwise they are read from the log files.
+        """
+        logger_api = _logger_api()
+        if logger_api:
+            try:
+                return self._query_logs(logger_api, log_lines, ps=ps, source=source,
+                                        since=since, until=until, grep=grep)
+            except requests.RequestException as e:
+                if since or until or grep:
+                    raise RuntimeError('Could not query deis-logger: {}'.format(e))
+                logger.warning('could not query deis-logger, reading the log files: %s', e)
+        elif since or until or grep:
+            raise RuntimeError('Filtering logs by time or pattern requires the deis-logger '
+                               'query API, which is not published')
+        if not self.has_logs():
+            raise EnvironmentError('Could not locate logs')
+        f = self._open_log()
+        try:
+            return unescape_log_lines(b''.join(self._log_history(log_lines, ps, source, f)))
+        finally:
+            if f is not None:
+                f.close()
+
+    def _query_logs(self, logger_api, log_lines, **filters):
+        """Return the log data deis-logger finds for this application with ``filters``."""
+        url, token = logger_api
+        params = dict((k, v) for k, v in filters.items() if v)
+        params['lines'] = log_lines
+        r = requests.get('{}/logs/{}'.format(url, self.id), params=params,
+                         headers={'Authorization': 'token {}'.format(token)},
+                         timeout=settings.LOG_QUERY_TIMEOUT)
+        if r.status_code == 404:
             raise EnvironmentError('Could not locate logs')
-        data = subprocess.check_output(['tail', '-n', log_lines, path])
-        return data
+        if r.status_code == 400:
+            raise ValueError(r.text.strip())
+        r.raise_for_status()
+        return r.content
+
+    def follow_logs(self, log_lines, ps=None, source=None, timeout=None):
+        """
+        Yield the most recent aggregated log data for this application, then yield each new
+        line as it is written to the log file.
+
+        When deis-logger rotates the log file, the new one is followed from its start. The
+        generator stops after ``timeout`` seconds so a request worker is never held forever;
+        clients are expected to reconnect.
+        """
+        timeout = timeout or settings.LOG_FOLLOW_TIMEOUT
+        deadline = time.time() + timeout
+        f = self._open_log()
+        try:
+            history = self._log_history(log_lines, ps, source, f)
+            if history:
+                yield unescape_log_lines(b''.join(history))
+            partial = b''
+            while time.time() < deadline:
+                line = f.readline() if f is not None else b''
+                if not line:
+                    f = self._reopen_log(f)
+                    time.sleep(settings.LOG_FOLLOW_INTERVAL)
+                    continue
+                partial += line
+                if not partial.endswith(b'\n'):
+                    continue
+                if log_line_matches(partial, ps, source):
+                    yield unescape_log_lines(partial)
+                partial = b''
+        finally:
+            if f is not None:
+                f.close()
+
+    def _reopen_log(self, f):
+        """
+        Return the file to go on following the log with once ``f`` has been read to its end:
+        the new log if the log was rotated, or ``f`` rewound if the log was truncated.
+        """
+        try:
+            current = os.stat(self.log_path)
+        except EnvironmentError:
+            # rotated, and nothing has been written since
+            return f
+        if f is None or os.fstat(f.fileno()).st_ino != current.st_ino:
+            if f is not None:
+                f.close()
+            return self._open_log()
+        if current.st_size < f.tell():
+            f.seek(0)
+        return f
 
     def run(self, user, command):
         """Run a one-off command in an ephemeral app container."""
+        c, escaped_command = self._create_run_container(user, command)
+        return c.run(escaped_command)
+
+    def run_interactive(self, user, command, tty=False, width=80, height=24):
+        """
+        Run a one-off command in an ephemeral app container and return a channel attached to
+        its stdin, stdout and stderr.
+        """
+        c, escaped_command = self._create_run_container(user, command)
+        return c.run_interactive(escaped_command, tty, width, height)
+
+    def _create_run_container(self, user, command):
+        """Create the database record for a one-off command and shell-escape the command."""
         # FIXME: remove the need for SSH private keys by using
         # a scheduler that supports one-off admin tasks natively
         if not settings.SSH_PRIVATE_KEY:
             raise EnvironmentError('Support for admin commands is not configured')
         if self.release_set.latest().build is None:
             raise EnvironmentError('No build associated with this release to run this command')
-        # TODO: add support for interactive shell
         msg = "{} runs '{}'".format(user.username, command)
         log_event(self, msg)
         c_num = max([c.num for c in self.container_set.filter(type='run')] or [0]) + 1
@@ -409,7 +602,7 @@ class App(UuidAuditedModel):
                                       image)
         # SECURITY: shell-escape user input
         escaped_command = command.replace("'", "'\\''")
-        return c.run(escaped_command)
+        return c, escaped_command
 
 
 @python_2_unicode_compatible
@@ -527,11 +720,37 @@ class Container(UuidAuditedModel):
 
     def run(self, command):
         """Run a one-off command"""
+        entrypoint, command = self._run_entrypoint(command)
+        image = self.release.image
+        job_id = self._job_id
+        try:
+            rc, output = self._scheduler.run(job_id, image, entrypoint, command)
+            return rc, output
+        except Exception as e:
+            err = '{} (run): {}'.format(job_id, e)
+            log_event(self.app, err, logging.ERROR)
+            raise
+
+    def run_interactive(self, command, tty=False, width=80, height=24):
+        """Run a one-off command and return a channel attached to its stdin, stdout and stderr"""
+        entrypoint, command = self._run_entrypoint(command)
+        image = self.release.image
+        job_id = self._job_id
+        try:
+            return self._scheduler.run_interactive(job_id, image, entrypoint, command,
+                                                   tty, width, height)
+        except NotImplementedError:
+            raise
+        except Exception as e:
+            err = '{} (run): {}'.format(job_id, e)
+            log_event(self.app, err, logging.ERROR)
+            raise
+
+    def _run_entrypoint(self, command):
+        """Return the entrypoint and arguments used to run a one-off command"""
         if self.release.build is None:
             raise EnvironmentError('No build associated with this release '
                                    'to run this command')
-        image = self.release.image
-        job_id = self._job_id
         entrypoint = '/bin/bash'
         # if this is a procfile-based app, switch the entrypoint to slugrunner's default
         # FIXME: remove slugrunner's hardcoded entrypoint
@@ -542,13 +761,7 @@ class Container(UuidAuditedModel):
             command = "'{}'".format(command)
         else:
             command = "-c '{}'".format(command)
-        try:
-            rc, output = self._scheduler.run(job_id, image, entrypoint, command)
-            return rc, output
-        except Exception as e:
-            err = '{} (run): {}'.format(job_id, e)
-            log_event(self.app, err, logging.ERROR)
-            raise
+        return entrypoint, command
 
 
 @python_2_unicode_compatible
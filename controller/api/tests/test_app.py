@@ -6,16 +6,20 @@ Run the tests with "./manage.py test api"
 
 from __future__ import unicode_literals
 
+import errno
 import json
 import mock
 import os.path
 import requests
+import socket
+import threading
 
 from django.conf import settings
 from django.contrib.auth.models import User
 from django.test import TestCase
 from rest_framework.authtoken.models import Token
 
+from api import attach
 from api.models import App
 
 
@@ -131,6 +135,180 @@ class AppTest(TestCase):
         os.remove(path)
         # TODO: test run needs an initial build
 
+    def test_app_logs_filters(self):
+        url = '/v1/apps'
+        body = {'id': 'autotest'}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        app_id = response.data['id']  # noqa
+        if not os.path.exists(settings.DEIS_LOG_DIR):
+            os.mkdir(settings.DEIS_LOG_DIR)
+        path = os.path.join(settings.DEIS_LOG_DIR, app_id + '.log')
+        with open(path, 'w') as f:
+            f.write(FAKE_APP_LOG_DATA)
+        url = '/v1/apps/{app_id}/logs'.format(**locals())
+        response = self.client.get(url + '?source=deis',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, FAKE_APP_LOG_DATA.splitlines(True)[0])
+        response = self.client.get(url + '?source=app',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, ''.join(FAKE_APP_LOG_DATA.splitlines(True)[1:]))
+        response = self.client.get(url + '?ps=worker',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, FAKE_APP_LOG_DATA.splitlines(True)[3])
+        response = self.client.get(url + '?ps=web.2&log_lines=1',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, FAKE_APP_LOG_DATA.splitlines(True)[2])
+        # the tail endpoint streams the same filtered data
+        url = '/v1/apps/{app_id}/logs/tail'.format(**locals())
+        with self.settings(LOG_FOLLOW_TIMEOUT=0.1, LOG_FOLLOW_INTERVAL=0.01):
+            response = self.client.get(url + '?ps=web',
+                                       HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(response.status_code, 200)
+            self.assertEqual(''.join(response.streaming_content),
+                             ''.join(FAKE_APP_LOG_DATA.splitlines(True)[1:3]))
+        os.remove(path)
+        response = self.client.get(url, HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 204)
+
+    def test_app_logs_tail_limit(self):
+        """Each worker follows a limited number of log streams at once."""
+        url = '/v1/apps'
+        body = {'id': 'autotest'}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        app_id = response.data['id']  # noqa
+        if not os.path.exists(settings.DEIS_LOG_DIR):
+            os.mkdir(settings.DEIS_LOG_DIR)
+        path = os.path.join(settings.DEIS_LOG_DIR, app_id + '.log')
+        with open(path, 'w') as f:
+            f.write(FAKE_APP_LOG_DATA)
+        url = '/v1/apps/{app_id}/logs/tail'.format(**locals())
+        with mock.patch('api.views.LOG_FOLLOWERS', threading.BoundedSemaphore(1)):
+            following = self.client.get(url, HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(following.status_code, 200)
+            response = self.client.get(url, HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(response.status_code, 503)
+            self.assertEqual(response['Retry-After'], '30')
+            # closing a stream, even one never read, makes room for another
+            following.close()
+            with self.settings(LOG_FOLLOW_TIMEOUT=0.1, LOG_FOLLOW_INTERVAL=0.01):
+                response = self.client.get(url, HTTP_AUTHORIZATION='token {}'.format(self.token))
+                self.assertEqual(response.status_code, 200)
+                self.assertEqual(''.join(response.streaming_content), FAKE_APP_LOG_DATA)
+            response = self.client.get(url, HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(response.status_code, 200)
+            response.close()
+        os.remove(path)
+
+    def test_app_logs_multiline(self):
+        """Events stored by deis-logger as one line are returned with their newlines."""
+        url = '/v1/apps'
+        body = {'id': 'autotest'}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        app_id = response.data['id']  # noqa
+        if not os.path.exists(settings.DEIS_LOG_DIR):
+            os.mkdir(settings.DEIS_LOG_DIR)
+        path = os.path.join(settings.DEIS_LOG_DIR, app_id + '.log')
+        with open(path, 'wb') as f:
+            f.write(FAKE_APP_LOG_DATA.splitlines(True)[0].encode('utf-8'))
+            f.write(b'2013-08-15T12:41:29UTC autotest[web.1]: '
+                    b'Exception\xe2\x80\xa8\tat Main.main\n')
+        event = b'2013-08-15T12:41:29UTC autotest[web.1]: Exception\n\tat Main.main\n'
+        url = '/v1/apps/{app_id}/logs'.format(**locals())
+        response = self.client.get(url + '?log_lines=1',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, event)
+        response = self.client.get(url + '?ps=web',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, event)
+        os.remove(path)
+
+    def test_app_logs_rotated(self):
+        """Logs are read back across a rotation, and followed into the new log file."""
+        url = '/v1/apps'
+        body = {'id': 'autotest'}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        app = App.objects.get(id=response.data['id'])
+        if not os.path.exists(settings.DEIS_LOG_DIR):
+            os.mkdir(settings.DEIS_LOG_DIR)
+        lines = FAKE_APP_LOG_DATA.splitlines(True)
+        segment = app.log_path + '.20130815T124128.000'
+        with open(segment, 'w') as f:
+            f.write(''.join(lines[:3]))
+        with open(app.log_path, 'w') as f:
+            f.write(lines[3])
+        url = '/v1/apps/{}/logs'.format(app.id)
+        response = self.client.get(url + '?log_lines=2',
+                                   HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 200)
+        self.assertEqual(response.data, ''.join(lines[2:]))
+
+        with self.settings(LOG_FOLLOW_TIMEOUT=0.1, LOG_FOLLOW_INTERVAL=0.01):
+            # rotated, with nothing written since: the old log is not replayed
+            stream = app.follow_logs(1)
+            self.assertEqual(next(stream), lines[3])
+            os.rename(app.log_path, segment)
+            self.assertEqual(list(stream), [])
+            # rotated, then written to: the new log is followed from its start
+            with open(app.log_path, 'w') as f:
+                f.write(lines[3])
+            stream = app.follow_logs(1)
+            self.assertEqual(next(stream), lines[3])
+            os.rename(app.log_path, segment)
+            with open(app.log_path, 'w') as f:
+                f.write(lines[0])
+            self.assertEqual(list(stream), [lines[0]])
+        os.remove(app.log_path)
+        os.remove(segment)
+
+    def test_app_logs_query_api(self):
+        """Logs are queried from deis-logger when its query API is published."""
+        url = '/v1/apps'
+        body = {'id': 'autotest'}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        app_id = response.data['id']  # noqa
+        url = '/v1/apps/{app_id}/logs'.format(**locals())
+        # filtering by time or pattern needs the query API
+        with mock.patch('api.models._logger_api', return_value=None):
+            response = self.client.get(url + '?since=1h',
+                                       HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(response.status_code, 503)
+        logged = requests.Response()
+        logged.status_code = 200
+        logged._content = FAKE_APP_LOG_DATA.splitlines(True)[2].encode('utf-8')
+        with mock.patch('api.models._logger_api', return_value=('http://10.0.0.1:8088', 's3')), \
+                mock.patch('requests.get', return_value=logged) as get:
+            response = self.client.get(url + '?log_lines=5&ps=web&since=1h&grep=port',
+                                       HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(response.status_code, 200)
+            self.assertEqual(response.data, FAKE_APP_LOG_DATA.splitlines(True)[2])
+            args, kwargs = get.call_args
+            self.assertEqual(args, ('http://10.0.0.1:8088/logs/{}'.format(app_id),))
+            self.assertEqual(kwargs['params'],
+                             {'lines': '5', 'ps': 'web', 'since': '1h', 'grep': 'port'})
+            self.assertEqual(kwargs['headers'], {'Authorization': 'token s3'})
+            logged.status_code = 400
+            logged._content = b'invalid grep'
+            response = self.client.get(url + '?grep=(',
+                                       HTTP_AUTHORIZATION='token {}'.format(self.token))
+            self.assertEqual(response.status_code, 400)
+            self.assertEqual(response.data, {'detail': 'invalid grep'})
+
     def test_app_release_notes_in_logs(self):
         """Verifies that an app's release summary is dumped into the logs."""
         url = '/v1/apps'
@@ -285,6 +463,58 @@ class AppTest(TestCase):
         self.assertEqual(response.data, {'detail': 'No build associated with this '
                                                    'release to run this command'})
 
+    @mock.patch('requests.post', mock_import_repository_task)
+    def test_run_attach(self):
+        """
+        An interactive run relays stdin to the command and its output and exit code back
+        over the hijacked connection.
+        """
+        url = '/v1/apps'
+        response = self.client.post(url, HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        app_id = response.data['id']
+        url = '/v1/apps/{app_id}/builds'.format(**locals())
+        body = {'image': 'autotest/example'}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 201)
+        # without a hijackable connection the server refuses to attach
+        url = '/v1/apps/{app_id}/run/attach'.format(**locals())
+        body = {'command': 'cat', 'tty': False}
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token))
+        self.assertEqual(response.status_code, 501)
+        server, client = socket.socketpair()
+        self.addCleanup(server.close)
+        self.addCleanup(client.close)
+        client.sendall(attach.encode_frame(attach.STDIN, b'hi\n') +
+                       attach.encode_frame(attach.STDIN, b''))
+        response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                    HTTP_AUTHORIZATION='token {}'.format(self.token),
+                                    **{'gunicorn.socket': server})
+        # the relay closed the connection, so no response is written to it
+        self.assertTrue(response.streaming)
+        with self.assertRaises(socket.error) as e:
+            list(response.streaming_content)
+        self.assertEqual(e.exception.errno, errno.EPIPE)
+        data = b''
+        while True:
+            chunk = client.recv(4096)
+            if not chunk:
+                break
+            data += chunk
+        self.assertTrue(data.startswith(attach.UPGRADE_RESPONSE))
+        frames = attach.FrameReader().feed(data[len(attach.UPGRADE_RESPONSE):])
+        self.assertEqual(frames, [(attach.STDOUT, b'hi\n'),
+                                  (attach.EXIT, attach.EXIT_PAYLOAD.pack(0))])
+        # each worker relays a limited number of interactive commands at once
+        with mock.patch('api.views.RUN_SESSIONS', threading.Semaphore(0)):
+            response = self.client.post(url, json.dumps(body), content_type='application/json',
+                                        HTTP_AUTHORIZATION='token {}'.format(self.token),
+                                        **{'gunicorn.socket': server})
+            self.assertEqual(response.status_code, 503)
+            self.assertEqual(response['Retry-After'], '30')
+
     def test_unauthorized_user_cannot_see_app(self):
         """
         An unauthorized user should not be able to access an app's resources.
@@ -331,3 +561,10 @@ FAKE_LOG_DATA = """
 2013-08-15 12:41:25 [33454] [INFO] Using worker: sync
 2013-08-15 12:41:25 [33457] [INFO] Booting worker with pid 33457
 """
+
+FAKE_APP_LOG_DATA = """\
+2013-08-15T12:41:25UTC deis[api]: autotest created initial release
+2013-08-15T12:41:26UTC autotest[web.1]: Listening on port 5000
+2013-08-15T12:41:27UTC autotest[web.2]: Listening on port 5000
+2013-08-15T12:41:28UTC autotest[worker.1]: Starting worker
+"""
@@ -6,14 +6,16 @@ Run the tests with "./manage.py test api"
 
 from __future__ import unicode_literals
 
+import base64
 import json
+import mock
 
 from django.conf import settings
 from django.contrib.auth.models import User
 from django.test import TransactionTestCase
 from rest_framework.authtoken.models import Token
 
-from scheduler import chaos
+from scheduler import chaos, fleet
 
 
 class SchedulerTest(TransactionTestCase):
@@ -393,3 +395,23 @@ class SchedulerTest(TransactionTestCase):
         self.assertEqual(response.status_code, 503)
         self.assertEqual(response.data, {'detail': 'exit code 1'})
         self.assertEqual(response.get('content-type'), 'application/json')
+
+    @mock.patch('paramiko.RSAKey')
+    @mock.patch('paramiko.Transport')
+    def test_fleet_run_interactive_closes_transport(self, mock_transport, mock_rsakey):
+        """Closing an interactive run's channel closes its SSH connection as well."""
+        client = fleet.FleetHTTPClient('/var/run/fleet.sock', None, {}, base64.b64encode('key'))
+        client._get_machines = lambda: {'machines': [{'primaryIP': '10.0.0.1'}]}
+        tran = mock_transport.return_value
+        chan = tran.open_session.return_value
+        chan.get_transport.return_value = tran
+        run = client.run_interactive('autotest_run_1', 'autotest/example', 'cat', 'cat')
+        run.close()
+        chan.close.assert_called_once_with()
+        tran.close.assert_called_once_with()
+        # a connection that fails to start a command is closed too
+        tran.reset_mock()
+        tran.open_session.side_effect = EOFError
+        with self.assertRaises(EOFError):
+            client.run_interactive('autotest_run_2', 'autotest/example', 'cat', 'cat')
+        tran.close.assert_called_once_with()
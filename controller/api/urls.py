@@ -52,8 +52,12 @@ urlpatterns = patterns(
     # application actions
     url(r"^apps/(?P<id>{})/scale/?".format(settings.APP_URL_REGEX),
         views.AppViewSet.as_view({'post': 'scale'})),
+    url(r"^apps/(?P<id>{})/logs/tail/?".format(settings.APP_URL_REGEX),
+        views.AppViewSet.as_view({'get': 'logs_tail'})),
     url(r"^apps/(?P<id>{})/logs/?".format(settings.APP_URL_REGEX),
         views.AppViewSet.as_view({'get': 'logs'})),
+    url(r"^apps/(?P<id>{})/run/attach/?".format(settings.APP_URL_REGEX),
+        views.AppViewSet.as_view({'post': 'run_attach'})),
     url(r"^apps/(?P<id>{})/run/?".format(settings.APP_URL_REGEX),
         views.AppViewSet.as_view({'post': 'run'})),
     # apps sharing
@@ -1,9 +1,13 @@
 """
 RESTful view classes for presenting Deis API objects.
 """
+import socket
+import threading
+
 from django.conf import settings
 from django.core.exceptions import ValidationError
 from django.contrib.auth.models import User
+from django.http import StreamingHttpResponse
 from django.shortcuts import get_object_or_404
 from guardian.shortcuts import assign_perm, get_objects_for_user, \
     get_users_with_perms, remove_perm
@@ -15,7 +19,38 @@ from rest_framework.response import Response
 from rest_framework.viewsets import GenericViewSet
 from rest_framework.authtoken.models import Token
 
-from api import authentication, models, permissions, serializers, viewsets
+from api import attach, authentication, models, permissions, serializers, viewsets
+
+
+# limits the log streams this worker process follows at once
+LOG_FOLLOWERS = threading.BoundedSemaphore(settings.LOG_FOLLOW_MAX)
+# limits the interactive commands this worker process relays at once
+RUN_SESSIONS = threading.BoundedSemaphore(settings.RUN_ATTACH_MAX)
+
+
+class FollowedLogs(object):
+    """
+    A followed log stream holding a slot of ``followers``, which it gives back once the
+    stream ends or the response is closed, whether or not the stream was read.
+    """
+
+    def __init__(self, stream, followers):
+        self.stream = stream
+        self.followers = followers
+        self.closed = False
+
+    def __iter__(self):
+        try:
+            for chunk in self.stream:
+                yield chunk
+        finally:
+            self.close()
+
+    def close(self):
+        if not self.closed:
+            self.closed = True
+            self.stream.close()
+            self.followers.release()
 
 
 class UserRegistrationViewSet(GenericViewSet,
@@ -192,12 +227,38 @@ class AppViewSet(BaseDeisViewSet):
         app = self.get_object()
         try:
             return Response(app.logs(request.query_params.get('log_lines',
-                                     str(settings.LOG_LINES))),
+                                     str(settings.LOG_LINES)),
+                                     ps=request.query_params.get('ps'),
+                                     source=request.query_params.get('source'),
+                                     since=request.query_params.get('since'),
+                                     until=request.query_params.get('until'),
+                                     grep=request.query_params.get('grep')),
                             status=status.HTTP_200_OK, content_type='text/plain')
         except EnvironmentError:
             return Response("No logs for {}".format(app.id),
                             status=status.HTTP_204_NO_CONTENT,
                             content_type='text/plain')
+        except ValueError as e:
+            return Response({'detail': str(e)}, status=status.HTTP_400_BAD_REQUEST)
+        except RuntimeError as e:
+            return Response({'detail': str(e)}, status=status.HTTP_503_SERVICE_UNAVAILABLE)
+
+    def logs_tail(self, request, **kwargs):
+        """Stream an application's logs over a chunked HTTP response as they are written."""
+        app = self.get_object()
+        if not app.has_logs():
+            return Response("No logs for {}".format(app.id),
+                            status=status.HTTP_204_NO_CONTENT,
+                            content_type='text/plain')
+        if not LOG_FOLLOWERS.acquire(False):
+            return Response({'detail': 'Too many clients are following logs, try again later'},
+                            status=status.HTTP_503_SERVICE_UNAVAILABLE,
+                            headers={'Retry-After': '30'})
+        stream = app.follow_logs(request.query_params.get('log_lines', str(settings.LOG_LINES)),
+                                 ps=request.query_params.get('ps'),
+                                 source=request.query_params.get('source'))
+        return StreamingHttpResponse(FollowedLogs(stream, LOG_FOLLOWERS),
+                                     content_type='text/plain')
 
     def run(self, request, **kwargs):
         app = self.get_object()
@@ -210,6 +271,54 @@ class AppViewSet(BaseDeisViewSet):
         return Response(output_and_rc, status=status.HTTP_200_OK,
                         content_type='text/plain')
 
+    def run_attach(self, request, **kwargs):
+        """
+        Run a one-off command interactively over the hijacked client connection.
+
+        The connection is upgraded and then carries the frames described in api.attach
+        until the command exits, and is closed once it has, so no response is written to it.
+        """
+        app = self.get_object()
+        sock = request.META.get('gunicorn.socket')
+        if sock is None:
+            return Response({'detail': 'Interactive commands are not supported by this server'},
+                            status=status.HTTP_501_NOT_IMPLEMENTED)
+        if not RUN_SESSIONS.acquire(False):
+            return Response({'detail': 'Too many interactive commands are running, '
+                                       'try again later'},
+                            status=status.HTTP_503_SERVICE_UNAVAILABLE,
+                            headers={'Retry-After': '30'})
+        try:
+            try:
+                tty = bool(request.data.get('tty', False))
+                width = int(request.data.get('width', 80))
+                height = int(request.data.get('height', 24))
+                chan = app.run_interactive(self.request.user, request.data['command'],
+                                           tty, width, height)
+            except (KeyError, ValueError):
+                return Response(
+                    {'detail': 'command is required; width and height must be integers'},
+                    status=status.HTTP_400_BAD_REQUEST)
+            except EnvironmentError as e:
+                return Response({'detail': str(e)}, status=status.HTTP_400_BAD_REQUEST)
+            except NotImplementedError:
+                return Response(
+                    {'detail': 'Interactive commands are not supported by this scheduler'},
+                    status=status.HTTP_501_NOT_IMPLEMENTED)
+            except RuntimeError as e:
+                return Response({'detail': str(e)}, status=status.HTTP_503_SERVICE_UNAVAILABLE)
+            try:
+                attach.relay(sock, chan)
+            finally:
+                chan.close()
+                try:
+                    sock.shutdown(socket.SHUT_RDWR)
+                except socket.error:
+                    pass
+        finally:
+            RUN_SESSIONS.release()
+        return StreamingHttpResponse(attach.hijacked_body())
+
 
 class BuildViewSet(ReleasableViewSet):
     """A viewset for interacting with Build objects."""
@@ -10,6 +10,7 @@ import sys
 import tempfile
 import ldap
 
+from django.core.exceptions import ImproperlyConfigured
 from django_auth_ldap.config import LDAPSearch, GroupOfNamesType
 
 
@@ -281,6 +282,15 @@ ETCD_HOST, ETCD_PORT = os.environ.get('ETCD', '127.0.0.1:4001').split(',')[0].sp
 # default deis settings
 DEIS_LOG_DIR = os.path.abspath(os.path.join(__file__, '..', '..', 'logs'))
 LOG_LINES = 1000
+# seconds a followed log stream stays open, kept below the gunicorn worker timeout
+LOG_FOLLOW_TIMEOUT = 600
+# seconds between checks for new lines in a followed log stream
+LOG_FOLLOW_INTERVAL = 0.5
+# threads each gunicorn worker process serves requests with, set from
+# /deis/controller/threads in the container
+WORKER_THREADS = 8
+# seconds to wait for the deis-logger query API
+LOG_QUERY_TIMEOUT = 30
 TEMPDIR = tempfile.mkdtemp(prefix='deis')
 DEIS_DOMAIN = 'deisapp.local'
 
@@ -363,6 +373,15 @@ if os.path.exists('/templates/confd_settings.py'):
     sys.path.append('/templates')
     from confd_settings import *  # noqa
 
+# log streams each worker process follows at once, and interactive commands it relays at
+# once. Each holds a thread for as long as it runs, so together they take at most half
+# the threads and a few of them cannot starve short requests.
+if WORKER_THREADS < 4:
+    raise ImproperlyConfigured(
+        'WORKER_THREADS must be at least 4, got {}'.format(WORKER_THREADS))
+LOG_FOLLOW_MAX = WORKER_THREADS // 4
+RUN_ATTACH_MAX = WORKER_THREADS // 4
+
 # LDAP Backend Configuration
 # Should be always after the confd_settings import.
 LDAP_USER_SEARCH = LDAPSearch(
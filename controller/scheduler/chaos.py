@@ -38,6 +38,15 @@ class ChaosSchedulerClient(MockSchedulerClient):
             raise RuntimeError('exit code 1')
         return super(ChaosSchedulerClient, self).run(name, image, entrypoint, command)
 
+    def run_interactive(self, name, image, entrypoint, command, tty=False, width=80, height=24):
+        """
+        Run a one-off command attached to a channel
+        """
+        if random.random() < CREATE_ERROR_RATE:
+            raise RuntimeError('exit code 1')
+        return super(ChaosSchedulerClient, self).run_interactive(
+            name, image, entrypoint, command, tty, width, height)
+
     def start(self, name):
         """
         Start an idle job
@@ -4,6 +4,7 @@ import copy
 import httplib
 import json
 import paramiko
+import random
 import socket
 import re
 import time
@@ -32,6 +33,22 @@ class UHTTPConnection(httplib.HTTPConnection):
         self.sock = sock
 
 
+class TransportChannel(object):
+    """An SSH channel that owns its transport, closing both when it is closed."""
+
+    def __init__(self, chan):
+        self.chan = chan
+
+    def __getattr__(self, name):
+        return getattr(self.chan, name)
+
+    def close(self):
+        try:
+            self.chan.close()
+        finally:
+            self.chan.get_transport().close()
+
+
 class FleetHTTPClient(object):
 
     def __init__(self, target, auth, options, pkey):
@@ -336,6 +353,35 @@ class FleetHTTPClient(object):
         # return rc and output
         return rc, output
 
+    def run_interactive(self, name, image, entrypoint, command, tty=False, width=80, height=24):
+        """
+        Run a one-off command attached to an SSH channel.
+
+        Unlike run(), the container is started directly with docker rather than through a
+        fleet unit so its stdin, stdout and stderr stay attached to the returned channel.
+        """
+        machines = self._get_machines()
+        if not machines or not machines.get('machines'):
+            raise RuntimeError('no available hosts to run command')
+        primaryIP = random.choice(machines['machines'])['primaryIP']
+
+        # prepare ssh key
+        file_obj = cStringIO.StringIO(base64.b64decode(self.pkey))
+        pkey = paramiko.RSAKey(file_obj=file_obj)
+
+        tran = paramiko.Transport((primaryIP, 22))
+        try:
+            tran.connect(username='core', pkey=pkey)
+            chan = tran.open_session()
+            if tty:
+                chan.get_pty(width=width, height=height)
+            flags = '-i -t' if tty else '-i'
+            chan.exec_command(RUN_INTERACTIVE_COMMAND.format(**locals()))
+        except:
+            tran.close()
+            raise
+        return TransportChannel(chan)
+
     def state(self, name):
         systemdActiveStateMap = {
             "active": "up",
@@ -395,3 +441,5 @@ RUN_TEMPLATE = [
     {"section": "Service", "name": "ExecStart", "value": '''/bin/sh -c "IMAGE=$(etcdctl get /deis/registry/host 2>&1):$(etcdctl get /deis/registry/port 2>&1)/{image}; docker run --name {name} --entrypoint={entrypoint} -a stdout -a stderr $IMAGE {command}"'''},  # noqa
     {"section": "Service", "name": "TimeoutStartSec", "value": "20m"},
 ]
+
+RUN_INTERACTIVE_COMMAND = '''IMAGE=$(etcdctl get /deis/registry/host 2>&1):$(etcdctl get /deis/registry/port 2>&1)/{image}; docker pull $IMAGE >/dev/null && docker run --name {name} --rm {flags} --entrypoint={entrypoint} $IMAGE {command}'''  # noqa
@@ -106,6 +106,10 @@ class MarathonHTTPClient(object):
         """Run a one-off command"""
         return self.fleet.run(name, image, entrypoint, command)
 
+    def run_interactive(self, name, image, entrypoint, command, tty=False, width=80, height=24):
+        """Run a one-off command attached to an SSH channel"""
+        return self.fleet.run_interactive(name, image, entrypoint, command, tty, width, height)
+
     def state(self, name):
         try:
             for _ in xrange(POLL_ATTEMPTS):
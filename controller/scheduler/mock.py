@@ -51,6 +51,12 @@ class MockSchedulerClient(object):
                               'entrypoint': entrypoint,
                               'command': command})
 
+    def run_interactive(self, name, image, entrypoint, command, tty=False, width=80, height=24):
+        """
+        Run a one-off command attached to a channel
+        """
+        return MockChannel()
+
     def start(self, name):
         """
         Start a container
@@ -92,3 +98,47 @@ class MockSchedulerClient(object):
         return
 
 SchedulerClient = MockSchedulerClient
+
+
+class MockChannel(object):
+    """
+    Stand-in for an SSH channel that echoes stdin back on stdout and exits 0
+    once stdin is closed.
+    """
+
+    def __init__(self):
+        self.out = b''
+        self.exit_status = None
+        self.size = (80, 24)
+        self.closed = False
+
+    def sendall(self, data):
+        self.out += data
+
+    def shutdown_write(self):
+        self.exit_status = 0
+
+    def resize_pty(self, width=80, height=24):
+        self.size = (width, height)
+
+    def recv_ready(self):
+        return bool(self.out)
+
+    def recv(self, nbytes):
+        data, self.out = self.out[:nbytes], self.out[nbytes:]
+        return data
+
+    def recv_stderr_ready(self):
+        return False
+
+    def recv_stderr(self, nbytes):
+        return b''
+
+    def exit_status_ready(self):
+        return self.exit_status is not None
+
+    def recv_exit_status(self):
+        return self.exit_status
+
+    def close(self):
+        self.closed = True
@@ -83,6 +83,12 @@ class SwarmClient(object):
             rc = 1
             return rc, output
 
+    def run_interactive(self, name, image, entrypoint, command, tty=False, width=80, height=24):
+        """
+        Run a one-off command attached to a channel
+        """
+        raise NotImplementedError
+
     def _get_container_state(self, name):
         try:
             if self.docker_cli.inspect_container(name)['State']['Running']:
@@ -43,6 +43,12 @@ DATABASES = {
 # move log directory out of /app/deis
 DEIS_LOG_DIR = '/data/logs'
 
+# threads each gunicorn worker serves requests with, as in gconf.py
+try:
+    WORKER_THREADS = max(int({{ if exists "/deis/controller/threads" }}{{ getv "/deis/controller/threads" }}{{ else }}"not set"{{end}}), 4)
+except (NameError, ValueError):
+    WORKER_THREADS = 8
+
 {{ if exists "/deis/controller/registrationMode" }}
 REGISTRATION_MODE = '{{ getv "/deis/controller/registrationMode" }}'
 {{ end }}
@@ -7,6 +7,15 @@ except (NameError, ValueError):
         workers = multiprocessing.cpu_count() * 2 + 1
     except NotImplementedError:
         workers = 8
+# each worker serves requests from a pool of threads, so a client following logs holds a
+# thread rather than a whole worker process
+worker_class = 'gthread'
+try:
+    threads = int({{ if exists "/deis/controller/threads" }}{{ getv "/deis/controller/threads" }}{{ else }}"not set"{{end}})
+except (NameError, ValueError):
+    threads = 8
+# the controller keeps half the threads for short requests, see WORKER_THREADS in settings.py
+threads = max(threads, 4)
 proc_name = 'deis-controller'
 timeout = 1200
 pidfile = '/tmp/gunicorn.pid'
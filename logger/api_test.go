@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	h := requireToken("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for header, expected := range map[string]int{
+		"":             http.StatusUnauthorized,
+		"token wrong":  http.StatusUnauthorized,
+		"secret":       http.StatusUnauthorized,
+		"token secret": http.StatusOK,
+	} {
+		r, _ := http.NewRequest("GET", "/logs/myapp", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != expected {
+			t.Errorf("expected %d for %q; got %d", expected, header, w.Code)
+		}
+	}
+}
@@ -1,36 +1,227 @@
 package drain
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
-	"net"
 	"net/url"
 	"os"
+	"sync/atomic"
+	"time"
 )
 
-func SendToDrain(m string, drain string) error {
-	u, err := url.Parse(drain)
+// BufferSize is the number of messages a drain holds while it cannot deliver them. Messages
+// sent to a full drain are dropped.
+var BufferSize = 1024
+
+// Delays between attempts to reconnect a drain, doubling from the minimum up to the maximum.
+var (
+	MinReconnectDelay = time.Second
+	MaxReconnectDelay = time.Minute
+)
+
+// Config describes a drain. An empty App drains the logs of every app.
+type Config struct {
+	ID  string
+	URL string
+	App string
+}
+
+// Stats counts the messages a drain has delivered and dropped, and its failed attempts to
+// connect or deliver.
+type Stats struct {
+	ID        string `json:"id"`
+	App       string `json:"app,omitempty"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+	Errors    uint64 `json:"errors"`
+}
+
+// conn is a connection to a drain's destination.
+type conn interface {
+	// Write delivers a batch of messages. None of them count as delivered if it fails.
+	Write(batch []string) error
+	Close() error
+}
+
+// transport opens conns for a drain URL scheme. Messages are delivered one at a time unless
+// the transport batches them.
+type transport struct {
+	dial  func(u *url.URL) (conn, error)
+	batch bool
+}
+
+// transports are the supported drain URL schemes.
+var transports = map[string]transport{
+	"syslog":     {dial: dialSyslogUDP},
+	"syslog+tcp": {dial: dialSyslogTCP},
+	"syslog+tls": {dial: dialSyslogTLS},
+	"https":      {dial: dialHTTPS, batch: true},
+}
+
+// Limits of a batch, which is delivered when it reaches BatchSize messages or BatchBytes
+// bytes, or BatchInterval after its first message.
+var (
+	BatchSize     = 500
+	BatchBytes    = 1048576
+	BatchInterval = time.Second
+)
+
+// Timeout bounds connecting to a drain and delivering a batch to it.
+var Timeout = 10 * time.Second
+
+// TLSConfig is used by the syslog+tls and https drains. The server name is taken from the
+// drain URL.
+var TLSConfig = &tls.Config{}
+
+// Drain forwards log messages to a single destination. Messages are buffered and delivered by
+// a goroutine of its own over a persistent connection, which is reconnected with backoff.
+type Drain struct {
+	// counters come first to keep them 64-bit aligned for atomic access
+	delivered uint64
+	dropped   uint64
+	errors    uint64
+	Config
+	u          *url.URL
+	transport  transport
+	conn       conn
+	batch      []string
+	batchBytes int
+	queue      chan string
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// New validates c and starts a drain for it.
+func New(c Config) (*Drain, error) {
+	u, err := url.Parse(c.URL)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	uri := u.Host + u.Path
-	switch u.Scheme {
-	case "syslog":
-		sendToSyslogDrain(m, uri)
+	t, ok := transports[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("%s drain type is not implemented", u.Scheme)
+	}
+	d := &Drain{
+		Config:    c,
+		u:         u,
+		transport: t,
+		queue:     make(chan string, BufferSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go d.run()
+	return d, nil
+}
+
+// Matches reports whether the drain wants messages from app.
+func (d *Drain) Matches(app string) bool {
+	return d.App == "" || d.App == app
+}
+
+// Send queues m for delivery without blocking. It is dropped if the buffer is full.
+func (d *Drain) Send(m string) {
+	select {
+	case d.queue <- m:
 	default:
-		log.Println(u.Scheme + " drain type is not implemented.")
+		atomic.AddUint64(&d.dropped, 1)
 	}
-	return nil
 }
 
-func sendToSyslogDrain(m string, drain string) error {
-	conn, err := net.Dial("udp", drain)
-	if err != nil {
-		log.Print(err)
+// Stats returns the drain's counters.
+func (d *Drain) Stats() Stats {
+	return Stats{
+		ID:        d.ID,
+		App:       d.App,
+		Delivered: atomic.LoadUint64(&d.delivered),
+		Dropped:   atomic.LoadUint64(&d.dropped),
+		Errors:    atomic.LoadUint64(&d.errors),
+	}
+}
+
+// Close stops the drain and waits for it to disconnect. Messages still buffered or batched are
+// dropped.
+func (d *Drain) Close() {
+	close(d.stop)
+	<-d.done
+	atomic.AddUint64(&d.dropped, uint64(len(d.queue)))
+}
+
+func (d *Drain) run() {
+	defer close(d.done)
+	defer func() {
+		atomic.AddUint64(&d.dropped, uint64(len(d.batch)))
+		if d.conn != nil {
+			d.conn.Close()
+		}
+	}()
+	var interval <-chan time.Time
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-interval:
+			interval = nil
+			if !d.flush() {
+				return
+			}
+		case m := <-d.queue:
+			d.batch = append(d.batch, m)
+			d.batchBytes += len(m)
+			if d.transport.batch && len(d.batch) < BatchSize && d.batchBytes < BatchBytes {
+				if interval == nil {
+					interval = time.After(BatchInterval)
+				}
+				continue
+			}
+			interval = nil
+			if !d.flush() {
+				return
+			}
+		}
+	}
+}
+
+// flush delivers the batch, retrying with backoff until it succeeds. It returns false if the
+// drain was stopped first.
+func (d *Drain) flush() bool {
+	delay := MinReconnectDelay
+	for !d.deliver() {
+		select {
+		case <-d.stop:
+			return false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > MaxReconnectDelay {
+			delay = MaxReconnectDelay
+		}
+	}
+	atomic.AddUint64(&d.delivered, uint64(len(d.batch)))
+	d.batch = d.batch[:0]
+	d.batchBytes = 0
+	return true
+}
+
+// deliver writes the batch, connecting first if needed. A failed write closes the connection
+// so the next attempt reconnects.
+func (d *Drain) deliver() bool {
+	if d.conn == nil {
+		c, err := d.transport.dial(d.u)
+		if err != nil {
+			atomic.AddUint64(&d.errors, 1)
+			log.Printf("drain %s: %v\n", d.ID, err)
+			return false
+		}
+		d.conn = c
+	}
+	if err := d.conn.Write(d.batch); err != nil {
+		atomic.AddUint64(&d.errors, 1)
+		log.Printf("drain %s: %v\n", d.ID, err)
+		d.conn.Close()
+		d.conn = nil
+		return false
 	}
-	defer conn.Close()
-	fmt.Fprintf(conn, m)
-	return nil
+	return true
 }
 
 func getopt(name, dfault string) string {
@@ -0,0 +1,176 @@
+package drain
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConn records messages and fails to connect or write while down is set.
+type fakeConn struct {
+	mu       sync.Mutex
+	down     bool
+	dials    int
+	messages []string
+}
+
+func (f *fakeConn) dial(u *url.URL) (conn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dials++
+	if f.down {
+		return nil, errors.New("connection refused")
+	}
+	return f, nil
+}
+
+func (f *fakeConn) Write(batch []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("broken pipe")
+	}
+	f.messages = append(f.messages, batch...)
+	return nil
+}
+
+func (f *fakeConn) Close() error { return nil }
+
+func (f *fakeConn) setDown(down bool) {
+	f.mu.Lock()
+	f.down = down
+	f.mu.Unlock()
+}
+
+func (f *fakeConn) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.messages...)
+}
+
+func useFakeConn(t *testing.T) *fakeConn {
+	f := &fakeConn{}
+	transports["fake"] = transport{dial: f.dial}
+	MinReconnectDelay, MaxReconnectDelay = time.Millisecond, 4*time.Millisecond
+	return f
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewUnknownScheme(t *testing.T) {
+	if _, err := New(Config{ID: "a", URL: "gopher://example.com"}); err == nil {
+		t.Error("expected an error for an unknown drain type")
+	}
+}
+
+func TestDrainReconnects(t *testing.T) {
+	f := useFakeConn(t)
+	f.setDown(true)
+	d, err := New(Config{ID: "a", URL: "fake://drain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	d.Send("one")
+	d.Send("two")
+	waitFor(t, "reconnect attempts", func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.dials >= 3
+	})
+	f.setDown(false)
+	waitFor(t, "delivery", func() bool { return len(f.received()) == 2 && d.Stats().Delivered == 2 })
+
+	if !reflect.DeepEqual(f.received(), []string{"one", "two"}) {
+		t.Errorf("unexpected messages; got %v", f.received())
+	}
+	if stats := d.Stats(); stats.Delivered != 2 || stats.Dropped != 0 || stats.Errors < 3 {
+		t.Errorf("expected 2 delivered, 0 dropped and the failed dials; got %+v", stats)
+	}
+}
+
+func TestDrainDropsWhenFull(t *testing.T) {
+	f := useFakeConn(t)
+	f.setDown(true)
+	BufferSize = 2
+	defer func() { BufferSize = 1024 }()
+	d, err := New(Config{ID: "a", URL: "fake://drain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		d.Send("message")
+	}
+	d.Close()
+	if stats := d.Stats(); stats.Delivered != 0 || stats.Dropped != 10 {
+		t.Errorf("expected 0 delivered and 10 dropped; got %+v", stats)
+	}
+}
+
+func TestDrainsRouting(t *testing.T) {
+	f := useFakeConn(t)
+	g := &fakeConn{}
+	transports["other"] = transport{dial: g.dial}
+	ds := NewDrains()
+	defer ds.Close()
+	ds.Update([]Config{
+		{ID: "all", URL: "fake://all"},
+		{ID: "myapp", URL: "other://myapp", App: "myapp"},
+	})
+
+	ds.Send("myapp", "from myapp")
+	ds.Send("otherapp", "from otherapp")
+	ds.Send("", "from the platform")
+	waitFor(t, "delivery", func() bool {
+		stats := ds.Stats()
+		return len(f.received()) == 3 && len(g.received()) == 1 &&
+			stats[0].Delivered == 3 && stats[1].Delivered == 1
+	})
+
+	if !reflect.DeepEqual(g.received(), []string{"from myapp"}) {
+		t.Errorf("scoped drain got %v", g.received())
+	}
+	stats := ds.Stats()
+	if len(stats) != 2 || stats[0].ID != "all" || stats[0].Delivered != 3 ||
+		stats[1].ID != "myapp" || stats[1].Delivered != 1 {
+		t.Errorf("unexpected stats; got %+v", stats)
+	}
+}
+
+func TestDrainsUpdateKeepsUnchanged(t *testing.T) {
+	useFakeConn(t)
+	ds := NewDrains()
+	defer ds.Close()
+	ds.Update([]Config{{ID: "a", URL: "fake://a"}, {ID: "b", URL: "fake://b"}})
+	a, b := ds.drains["a"], ds.drains["b"]
+
+	ds.Update([]Config{{ID: "a", URL: "fake://a"}, {ID: "b", URL: "fake://b", App: "myapp"},
+		{ID: "c", URL: "gopher://c"}})
+	if ds.drains["a"] != a {
+		t.Error("an unchanged drain should be kept")
+	}
+	if ds.drains["b"] == b || ds.drains["b"].App != "myapp" {
+		t.Error("a changed drain should be replaced")
+	}
+	if _, ok := ds.drains["c"]; ok {
+		t.Error("an invalid drain should be skipped")
+	}
+
+	ds.Update(nil)
+	if len(ds.drains) != 0 {
+		t.Errorf("expected no drains; got %d", len(ds.drains))
+	}
+}
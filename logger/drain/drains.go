@@ -0,0 +1,88 @@
+package drain
+
+import (
+	"log"
+	"sort"
+	"sync"
+)
+
+// Drains routes messages to a changing set of drains.
+type Drains struct {
+	mu     sync.RWMutex
+	drains map[string]*Drain
+}
+
+// NewDrains creates an empty set of drains.
+func NewDrains() *Drains {
+	return &Drains{drains: make(map[string]*Drain)}
+}
+
+// Update replaces the set of drains with configs. Drains whose config is unchanged keep their
+// connection, buffer and counters. Invalid configs are logged and skipped.
+func (ds *Drains) Update(configs []Config) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	wanted := make(map[string]bool)
+	for _, c := range configs {
+		wanted[c.ID] = true
+		if d, ok := ds.drains[c.ID]; ok {
+			if d.Config == c {
+				continue
+			}
+			ds.remove(d)
+		}
+		d, err := New(c)
+		if err != nil {
+			log.Printf("drain %s: %v\n", c.ID, err)
+			continue
+		}
+		ds.drains[c.ID] = d
+	}
+	for id, d := range ds.drains {
+		if !wanted[id] {
+			ds.remove(d)
+		}
+	}
+}
+
+func (ds *Drains) remove(d *Drain) {
+	d.Close()
+	delete(ds.drains, d.ID)
+	stats := d.Stats()
+	log.Printf("drain %s stopped, %d messages delivered, %d dropped\n", d.ID, stats.Delivered,
+		stats.Dropped)
+}
+
+// Send queues m on every drain that wants messages from app.
+func (ds *Drains) Send(app, m string) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	for _, d := range ds.drains {
+		if d.Matches(app) {
+			d.Send(m)
+		}
+	}
+}
+
+// Stats returns the counters of every drain, sorted by ID.
+func (ds *Drains) Stats() []Stats {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	stats := make([]Stats, 0, len(ds.drains))
+	for _, d := range ds.drains {
+		stats = append(stats, d.Stats())
+	}
+	sort.Sort(statsByID(stats))
+	return stats
+}
+
+// Close stops every drain.
+func (ds *Drains) Close() {
+	ds.Update(nil)
+}
+
+type statsByID []Stats
+
+func (s statsByID) Len() int           { return len(s) }
+func (s statsByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s statsByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
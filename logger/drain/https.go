@@ -0,0 +1,72 @@
+package drain
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// httpsConn POSTs each batch as a gzipped body with one message per line.
+type httpsConn struct {
+	u      *url.URL
+	client *http.Client
+}
+
+func dialHTTPS(u *url.URL) (conn, error) {
+	return httpsConn{
+		u: u,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: TLSConfig,
+			},
+			Timeout: Timeout,
+		},
+	}, nil
+}
+
+func (c httpsConn) Write(batch []string) error {
+	var body bytes.Buffer
+	w := gzip.NewWriter(&body)
+	for _, m := range batch {
+		if _, err := io.WriteString(w, m+"\n"); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	// credentials go in a header so they stay out of error messages
+	u := *c.u
+	u.User = nil
+	req, err := http.NewRequest("POST", u.String(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Content-Encoding", "gzip")
+	if c.u.User != nil {
+		password, _ := c.u.User.Password()
+		req.SetBasicAuth(c.u.User.Username(), password)
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", c.u.Host, res.Status)
+	}
+	return nil
+}
+
+func (c httpsConn) Close() error {
+	c.client.Transport.(*http.Transport).CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,127 @@
+package drain
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCollector records the batches POSTed to it and fails while status is not 200.
+type fakeCollector struct {
+	mu      sync.Mutex
+	status  int
+	batches [][]string
+}
+
+func (f *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, password, _ := r.BasicAuth(); user != "deis" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.status != http.StatusOK {
+		w.WriteHeader(f.status)
+		return
+	}
+	if r.Method != "POST" || r.Header.Get("Content-Encoding") != "gzip" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body, err := gzip.NewReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var batch []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		batch = append(batch, scanner.Text())
+	}
+	f.batches = append(f.batches, batch)
+}
+
+func (f *fakeCollector) received() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.batches...)
+}
+
+func (f *fakeCollector) setStatus(status int) {
+	f.mu.Lock()
+	f.status = status
+	f.mu.Unlock()
+}
+
+func newHTTPSDrain(t *testing.T) (*fakeCollector, *httptest.Server, *Drain) {
+	f := &fakeCollector{status: http.StatusOK}
+	ts := httptest.NewTLSServer(f)
+	TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	MinReconnectDelay, MaxReconnectDelay = time.Millisecond, 4*time.Millisecond
+	d, err := New(Config{ID: "https", URL: strings.Replace(ts.URL, "https://",
+		"https://deis:secret@", 1) + "/logs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, ts, d
+}
+
+func TestHTTPSDrainBatchesBySize(t *testing.T) {
+	BatchSize, BatchInterval = 3, time.Hour
+	defer func() { BatchSize, BatchInterval, TLSConfig = 500, time.Second, &tls.Config{} }()
+	f, ts, d := newHTTPSDrain(t)
+	defer ts.Close()
+	defer d.Close()
+
+	for _, m := range []string{"one", "two", "three", "four"} {
+		d.Send(m)
+	}
+	waitFor(t, "a batch", func() bool { return len(f.received()) == 1 && d.Stats().Delivered > 0 })
+
+	batches := f.received()
+	if strings.Join(batches[0], ",") != "one,two,three" {
+		t.Errorf("unexpected batch; got %v", batches[0])
+	}
+	if stats := d.Stats(); stats.Delivered != 3 {
+		t.Errorf("expected 3 delivered, the last message waits for its batch; got %+v", stats)
+	}
+}
+
+func TestHTTPSDrainBatchesByTime(t *testing.T) {
+	BatchInterval = 10 * time.Millisecond
+	defer func() { BatchInterval, TLSConfig = time.Second, &tls.Config{} }()
+	f, ts, d := newHTTPSDrain(t)
+	defer ts.Close()
+	defer d.Close()
+
+	d.Send("one")
+	d.Send("two")
+	waitFor(t, "a batch", func() bool { return len(f.received()) == 1 })
+	if strings.Join(f.received()[0], ",") != "one,two" {
+		t.Errorf("unexpected batch; got %v", f.received()[0])
+	}
+}
+
+func TestHTTPSDrainRetries(t *testing.T) {
+	BatchInterval = 10 * time.Millisecond
+	defer func() { BatchInterval, TLSConfig = time.Second, &tls.Config{} }()
+	f, ts, d := newHTTPSDrain(t)
+	defer ts.Close()
+	defer d.Close()
+
+	f.setStatus(http.StatusServiceUnavailable)
+	d.Send("one")
+	time.Sleep(50 * time.Millisecond)
+	f.setStatus(http.StatusOK)
+	// the collector has the batch before the drain counts it as delivered
+	waitFor(t, "a batch", func() bool { return len(f.received()) == 1 && d.Stats().Delivered == 1 })
+	if stats := d.Stats(); stats.Dropped != 0 || stats.Errors == 0 {
+		t.Errorf("expected 0 dropped and the failed attempts; got %+v", stats)
+	}
+}
@@ -0,0 +1,71 @@
+package drain
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// syslogUDPConn sends each message as a datagram of its own.
+type syslogUDPConn struct {
+	net.Conn
+}
+
+func dialSyslogUDP(u *url.URL) (conn, error) {
+	c, err := net.Dial("udp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	return syslogUDPConn{c}, nil
+}
+
+func (c syslogUDPConn) Write(batch []string) error {
+	for _, m := range batch {
+		if _, err := io.WriteString(c.Conn, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syslogStreamConn frames messages by octet counting as in RFC 6587 and RFC 5425, so
+// messages may contain newlines.
+type syslogStreamConn struct {
+	net.Conn
+	w *bufio.Writer
+}
+
+func dialSyslogTCP(u *url.URL) (conn, error) {
+	c, err := net.DialTimeout("tcp", u.Host, Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return newSyslogStreamConn(c), nil
+}
+
+func dialSyslogTLS(u *url.URL) (conn, error) {
+	c, err := tls.DialWithDialer(&net.Dialer{Timeout: Timeout}, "tcp", u.Host, TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newSyslogStreamConn(c), nil
+}
+
+func newSyslogStreamConn(c net.Conn) syslogStreamConn {
+	return syslogStreamConn{Conn: c, w: bufio.NewWriter(c)}
+}
+
+func (c syslogStreamConn) Write(batch []string) error {
+	// reconnect to a destination that stopped reading rather than block forever
+	c.SetWriteDeadline(time.Now().Add(Timeout))
+	for _, m := range batch {
+		if _, err := fmt.Fprintf(c.w, "%d %s", len(m), m); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
@@ -0,0 +1,101 @@
+package drain
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSyslogUDPDrain(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	d, err := New(Config{ID: "udp", URL: "syslog://" + l.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	d.Send("2015-06-15T18:40:09UTC myapp[web.1]: hello")
+	buf := make([]byte, 1024)
+	l.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := l.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != "2015-06-15T18:40:09UTC myapp[web.1]: hello" {
+		t.Errorf("unexpected datagram; got %q", buf[:n])
+	}
+}
+
+// readOctetCounted reads count frames of "MSG-LEN SP MSG" from the first connection to l.
+func readOctetCounted(t *testing.T, l net.Listener, count int) []string {
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(c)
+	var messages []string
+	for i := 0; i < count; i++ {
+		var n int
+		if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+			t.Fatal(err)
+		}
+		m := make([]byte, n)
+		if _, err := io.ReadFull(r, m); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, string(m))
+	}
+	return messages
+}
+
+func testStreamDrain(t *testing.T, scheme string, l net.Listener) {
+	d, err := New(Config{ID: scheme, URL: scheme + "://" + l.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	d.Send("myapp[web.1]: a stack trace\n\tat line one")
+	d.Send("myapp[web.1]: done")
+	messages := readOctetCounted(t, l, 2)
+	if len(messages) != 2 || messages[0] != "myapp[web.1]: a stack trace\n\tat line one" ||
+		messages[1] != "myapp[web.1]: done" {
+		t.Errorf("unexpected messages; got %q", messages)
+	}
+}
+
+func TestSyslogTCPDrain(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	testStreamDrain(t, "syslog+tcp", l)
+}
+
+func TestSyslogTLSDrain(t *testing.T) {
+	// borrow the test certificate httptest serves with
+	ts := httptest.NewTLSServer(nil)
+	config := &tls.Config{Certificates: ts.TLS.Certificates}
+	ts.Close()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	defer func() { TLSConfig = &tls.Config{} }()
+	testStreamDrain(t, "syslog+tls", l)
+}
@@ -0,0 +1,38 @@
+package logstore
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// compress gzips segment to segment.gz and removes segment.
+func compress(segment string) error {
+	in, err := os.Open(segment)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(segment+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(out)
+	if _, err = io.Copy(w, in); err == nil {
+		err = w.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(segment + ".gz")
+		return err
+	}
+	// keep the time the segment was last written, which expiry goes by
+	os.Chtimes(segment+".gz", info.ModTime(), info.ModTime())
+	return os.Remove(segment)
+}
@@ -0,0 +1,175 @@
+package logstore
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	dtime "github.com/deis/deis/pkg/time"
+)
+
+var appNameRegex = regexp.MustCompile(`^[-a-z0-9]+$`)
+
+// MaxLines is the most lines a query returns, and the number it returns unless it asks for
+// fewer, so a query never holds every log of an app in memory.
+var MaxLines = 10000
+
+// NewHandler serves the logs in s over HTTP, one line of text per log line:
+//
+//	GET /logs/<app>?lines=&since=&until=&ps=&source=&grep=
+//	GET /logs/<app>/tail?lines=&ps=&source=&grep=
+//
+// since and until take an RFC 3339 or Deis time, or a duration such as 1h meaning that long
+// ago. source is deis or app. grep is a regular expression. A query returns the newest
+// matching lines, at most MaxLines. A tail writes the last lines first, then streams lines as
+// they are logged until the client goes away.
+func NewHandler(s *Store) http.Handler {
+	return &handler{store: s}
+}
+
+type handler struct {
+	store *Store
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "logs" || !appNameRegex.MatchString(parts[1]) ||
+		(len(parts) == 3 && parts[2] != "tail") {
+		http.NotFound(w, r)
+		return
+	}
+	q, err := parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(parts) == 3 {
+		q.Since, q.Until = time.Time{}, time.Time{}
+		h.tail(w, r, parts[1], q)
+		return
+	}
+	if q.Lines == 0 {
+		q.Lines = MaxLines
+	}
+	lines, err := h.store.Query(parts[1], q)
+	if err != nil {
+		writeError(w, parts[1], err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// tail subscribes before querying the last lines, so none are missed in between. Lines
+// written meanwhile may be sent twice.
+func (h *handler) tail(w http.ResponseWriter, r *http.Request, app string, q Query) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	live, cancel := h.store.Tail(app)
+	defer cancel()
+
+	var lines []string
+	if q.Lines > 0 {
+		var err error
+		if lines, err = h.store.Query(app, q); err != nil && err != ErrNotFound {
+			writeError(w, app, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	flusher.Flush()
+
+	var gone <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		gone = notifier.CloseNotify()
+	}
+	for {
+		select {
+		case line, ok := <-live:
+			if !ok {
+				return
+			}
+			if q.Match(line) {
+				fmt.Fprintln(w, line)
+				flusher.Flush()
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, app string, err error) {
+	if err == ErrNotFound {
+		http.Error(w, fmt.Sprintf("no logs found for %s", app), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// parseQuery reads a query from the parameters of r. A tail only takes lines, ps, source and
+// grep.
+func parseQuery(r *http.Request) (Query, error) {
+	var q Query
+	var err error
+	params := r.URL.Query()
+	if v := params.Get("lines"); v != "" {
+		if q.Lines, err = strconv.Atoi(v); err != nil || q.Lines < 0 {
+			return q, fmt.Errorf("lines must be a positive number, got %q", v)
+		}
+	}
+	if q.Lines > MaxLines {
+		q.Lines = MaxLines
+	}
+	if v := params.Get("since"); v != "" {
+		if q.Since, err = parseTime(v); err != nil {
+			return q, err
+		}
+	}
+	if v := params.Get("until"); v != "" {
+		if q.Until, err = parseTime(v); err != nil {
+			return q, err
+		}
+	}
+	q.Ps = params.Get("ps")
+	switch q.Source = params.Get("source"); q.Source {
+	case "", "deis", "app":
+	default:
+		return q, fmt.Errorf("source must be deis or app, got %q", q.Source)
+	}
+	if v := params.Get("grep"); v != "" {
+		if q.Grep, err = regexp.Compile(v); err != nil {
+			return q, fmt.Errorf("invalid grep: %v", err)
+		}
+	}
+	return q, nil
+}
+
+// parseTime parses an RFC 3339 or Deis time, or a duration before now.
+func parseTime(v string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, dtime.DeisDatetimeFormat} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time such as 2015-06-01T12:00:00Z or a duration such as 1h", v)
+}
@@ -0,0 +1,126 @@
+package logstore
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	dtime "github.com/deis/deis/pkg/time"
+)
+
+func TestHandlerQuery(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+	writeLines(s, 3)
+	server := httptest.NewServer(NewHandler(s))
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/logs/myapp?lines=1", 200, "2015-06-01T12:02:00UTC myapp[worker.1]: line 2\n"},
+		{"/logs/myapp?ps=web&since=2015-06-01T12:01:00Z&until=2015-06-01T12:01:00UTC", 200,
+			"2015-06-01T12:01:00UTC myapp[web.1]: line 1\n"},
+		{"/logs/myapp?grep=line+[12]$&ps=worker", 200,
+			"2015-06-01T12:01:00UTC myapp[worker.1]: line 1\n2015-06-01T12:02:00UTC myapp[worker.1]: line 2\n"},
+		{"/logs/myapp?since=1h", 200, ""},
+		{"/logs/myapp?lines=many", 400, ""},
+		{"/logs/myapp?since=yesterday", 400, ""},
+		{"/logs/myapp?grep=(", 400, ""},
+		{"/logs/other", 404, ""},
+		{"/logs/..", 404, ""},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: expected %d; got %d %s", tt.path, tt.status, resp.StatusCode, body)
+		} else if tt.status == 200 && string(body) != tt.expected {
+			t.Errorf("GET %s: expected %q; got %q", tt.path, tt.expected, body)
+		}
+	}
+}
+
+func TestHandlerQuerySourceAndLimit(t *testing.T) {
+	MaxLines = 2
+	defer func() { MaxLines = 10000 }()
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+	writeLines(s, 3)
+	s.Write("myapp", queryStart, []byte("2015-06-01T12:00:00UTC deis[api]: myapp scaled"))
+	server := httptest.NewServer(NewHandler(s))
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/logs/myapp?source=deis", 200, "2015-06-01T12:00:00UTC deis[api]: myapp scaled\n"},
+		{"/logs/myapp?source=app&ps=web&lines=1", 200, "2015-06-01T12:02:00UTC myapp[web.1]: line 2\n"},
+		// a query returns at most MaxLines, the newest
+		{"/logs/myapp?source=app", 200,
+			"2015-06-01T12:02:00UTC myapp[web.1]: line 2\n2015-06-01T12:02:00UTC myapp[worker.1]: line 2\n"},
+		{"/logs/myapp?lines=5&ps=web", 200,
+			"2015-06-01T12:01:00UTC myapp[web.1]: line 1\n2015-06-01T12:02:00UTC myapp[web.1]: line 2\n"},
+		{"/logs/myapp?source=platform", 400, ""},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: expected %d; got %d %s", tt.path, tt.status, resp.StatusCode, body)
+		} else if tt.status == 200 && string(body) != tt.expected {
+			t.Errorf("GET %s: expected %q; got %q", tt.path, tt.expected, body)
+		}
+	}
+}
+
+func TestHandlerTail(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	writeLines(s, 2)
+	server := httptest.NewServer(NewHandler(s))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/logs/myapp/tail?lines=1&ps=web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+	line, err := r.ReadString('\n')
+	if err != nil || line != "2015-06-01T12:01:00UTC myapp[web.1]: line 1\n" {
+		t.Fatalf("expected the last line first; got %q, %v", line, err)
+	}
+
+	now := queryStart.Add(time.Hour).Format(dtime.DeisDatetimeFormat)
+	s.Write("myapp", time.Now(), []byte(now+" myapp[worker.1]: skipped"))
+	s.Write("myapp", time.Now(), []byte(now+" myapp[web.1]: streamed"))
+	if line, err = r.ReadString('\n'); err != nil || line != now+" myapp[web.1]: streamed\n" {
+		t.Errorf("expected the new line to be streamed; got %q, %v", line, err)
+	}
+
+	// closing the store ends the stream
+	s.Close()
+	if rest, _ := ioutil.ReadAll(r); strings.TrimSpace(string(rest)) != "" {
+		t.Errorf("unexpected lines; got %q", rest)
+	}
+}
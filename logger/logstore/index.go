@@ -0,0 +1,81 @@
+package logstore
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexInterval is the number of log bytes between index entries.
+var IndexInterval int64 = 64 * 1024
+
+// indexDir holds an index for each log and segment, named after it with an .idx suffix.
+const indexDir = ".index"
+
+// indexEntrySize is the size of an entry on disk, its time in nanoseconds since the epoch
+// then its offset, both big endian.
+const indexEntrySize = 16
+
+// indexEntry records the time of the line starting at offset.
+type indexEntry struct {
+	time   time.Time
+	offset int64
+}
+
+// indexPath returns the index of a log or segment. A segment keeps its index when compressed.
+func (s *Store) indexPath(logPath string) string {
+	return path.Join(s.root, indexDir, strings.TrimSuffix(path.Base(logPath), ".gz")+".idx")
+}
+
+func writeIndexEntry(w io.Writer, e indexEntry) error {
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(e.time.UnixNano()))
+	binary.BigEndian.PutUint64(buf[8:], uint64(e.offset))
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// readIndex reads the entries of an index. A missing index has no entries.
+func readIndex(indexPath string) ([]indexEntry, error) {
+	contents, err := ioutil.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries := make([]indexEntry, 0, len(contents)/indexEntrySize)
+	for i := 0; i+indexEntrySize <= len(contents); i += indexEntrySize {
+		entries = append(entries, indexEntry{
+			time:   time.Unix(0, int64(binary.BigEndian.Uint64(contents[i:i+8]))),
+			offset: int64(binary.BigEndian.Uint64(contents[i+8 : i+16])),
+		})
+	}
+	return entries, nil
+}
+
+// seekRange uses entries to narrow the bytes of a log holding lines between since and until,
+// either of which may be zero. It assumes lines are roughly in time order, which holds as
+// they are written as they arrive. The end is -1 for the end of the log.
+func seekRange(entries []indexEntry, since, until time.Time) (int64, int64) {
+	start, end := int64(0), int64(-1)
+	if !since.IsZero() {
+		// the last entry before since, as lines after it may still be in range
+		i := sort.Search(len(entries), func(i int) bool { return !entries[i].time.Before(since) })
+		if i > 0 {
+			start = entries[i-1].offset
+		}
+	}
+	if !until.IsZero() {
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].time.After(until) })
+		if i < len(entries) {
+			end = entries[i].offset
+		}
+	}
+	return start, end
+}
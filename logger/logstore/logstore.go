@@ -0,0 +1,515 @@
+// Package logstore keeps the log files of each app in a directory, rotating, compressing
+// and expiring them according to a retention policy.
+//
+// The current log of an app is <app>.log. Rotated segments are renamed to
+// <app>.log.<timestamp> and then gzipped to <app>.log.<timestamp>.gz. Each log and segment has
+// a sparse index of line times and offsets in the .index directory, so queries for a time
+// range can skip to the lines in it.
+//
+// Each log event is stored as one line, the newlines within it replaced by LineSeparator.
+// Query and Tail return events with their newlines.
+package logstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy decides when an app's log is rotated and how long its rotated segments are kept. A
+// zero limit disables it.
+type Policy struct {
+	// MaxSize rotates the log once it holds this many bytes.
+	MaxSize int64
+	// MaxAge rotates the log once this long has passed since it was started.
+	MaxAge time.Duration
+	// MaxSegments is the number of rotated segments kept.
+	MaxSegments int
+	// MaxSegmentAge deletes rotated segments last written longer ago than this.
+	MaxSegmentAge time.Duration
+}
+
+// DefaultPolicy is used for apps without a policy of their own until SetPolicy is called.
+var DefaultPolicy = Policy{
+	MaxSize:     10 * 1024 * 1024,
+	MaxAge:      24 * time.Hour,
+	MaxSegments: 5,
+}
+
+// MaxOpenFiles is the number of log files kept open. The least recently written is closed
+// to make room for another.
+var MaxOpenFiles = 128
+
+// MaintenanceInterval is how often logs are checked for age based rotation and expiry.
+var MaintenanceInterval = time.Minute
+
+// LineSeparator stands for the newlines within a log event, such as a stack trace joined by
+// logspout, so the event is stored as one line.
+const LineSeparator = "\u2028"
+
+// segmentFormat names rotated segments so they sort in the order they were rotated.
+const segmentFormat = "20060102T150405.000"
+
+type logFile struct {
+	*os.File
+	idx         *os.File
+	size        int64
+	lastIndexed int64
+	started     time.Time
+	lastWrite   time.Time
+}
+
+// index records t and the offset of the next line every IndexInterval bytes.
+func (f *logFile) index(t time.Time) error {
+	if f.lastIndexed >= 0 && f.size-f.lastIndexed < IndexInterval {
+		return nil
+	}
+	// the controller appends to logs as well, so ask for the real offset
+	offset, err := f.Seek(0, os.SEEK_END)
+	if err != nil {
+		return err
+	}
+	f.size = offset
+	if err = writeIndexEntry(f.idx, indexEntry{time: t, offset: offset}); err != nil {
+		return err
+	}
+	f.lastIndexed = offset
+	return nil
+}
+
+func (f *logFile) Close() error {
+	f.idx.Close()
+	return f.File.Close()
+}
+
+// Store writes app logs to files under a root directory.
+type Store struct {
+	root     string
+	mu       sync.Mutex
+	files    map[string]*logFile
+	policy   Policy
+	policies map[string]Policy
+	tails    map[string]map[chan string]bool
+	// expiring is set while a job to expire the segments of every app is queued
+	expiring bool
+	// jobs queues compression and expiry for the worker. Queueing never blocks, so it is done
+	// under mu without holding up writes behind a slow worker.
+	jobsMu     sync.Mutex
+	jobs       []func()
+	jobsReady  chan struct{}
+	jobsClosed bool
+	stop       chan struct{}
+	stopped    chan struct{}
+	done       chan struct{}
+}
+
+// New creates root if needed and starts a store in it.
+func New(root string) (*Store, error) {
+	if err := os.MkdirAll(path.Join(root, indexDir), 0777); err != nil {
+		return nil, err
+	}
+	s := &Store{
+		root:      root,
+		files:     make(map[string]*logFile),
+		tails:     make(map[string]map[chan string]bool),
+		policy:    DefaultPolicy,
+		jobsReady: make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.worker()
+	go s.maintain()
+	return s, nil
+}
+
+// Path returns the path of app's current log.
+func (s *Store) Path(app string) string {
+	return path.Join(s.root, app+".log")
+}
+
+// SetPolicy sets the policy of every app, with overrides for some.
+func (s *Store) SetPolicy(policy Policy, apps map[string]Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+	s.policies = apps
+}
+
+func (s *Store) policyFor(app string) Policy {
+	if p, ok := s.policies[app]; ok {
+		return p
+	}
+	return s.policy
+}
+
+// Write appends line to app's log, rotating it if it has grown too large. t is the time the
+// line was logged at, which the index goes by. Newlines within line are stored as
+// LineSeparator.
+func (s *Store) Write(app string, t time.Time, line []byte) error {
+	line = bytes.Replace(bytes.TrimRight(line, "\n"), []byte("\n"), []byte(LineSeparator), -1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.open(app)
+	if err != nil {
+		return err
+	}
+	if err = f.index(t); err != nil {
+		return err
+	}
+	n, err := f.Write(append(line, '\n'))
+	f.size += int64(n)
+	f.lastWrite = time.Now()
+	if err != nil {
+		return err
+	}
+	s.publish(app, unescapeNewlines(string(line)))
+	if p := s.policyFor(app); p.MaxSize > 0 && f.size >= p.MaxSize {
+		return s.rotate(app)
+	}
+	return nil
+}
+
+// unescapeNewlines turns the LineSeparators of a stored line back into newlines.
+func unescapeNewlines(line string) string {
+	return strings.Replace(line, LineSeparator, "\n", -1)
+}
+
+// open returns the cached handle of app's log, opening it if needed.
+func (s *Store) open(app string) (*logFile, error) {
+	if f, ok := s.files[app]; ok {
+		return f, nil
+	}
+	if len(s.files) >= MaxOpenFiles {
+		s.closeLeastRecent()
+	}
+	file, err := os.OpenFile(s.Path(app), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f := &logFile{File: file, size: info.Size(), started: s.lastRotation(app)}
+	if f.idx, f.lastIndexed, err = openIndex(s.indexPath(s.Path(app)), f.size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.files[app] = f
+	return f, nil
+}
+
+// openIndex opens the index of a log holding size bytes and returns the offset of its last
+// entry, or -1 if it has none. An index left behind by a log that was since replaced is
+// started over.
+func openIndex(indexPath string, size int64) (*os.File, int64, error) {
+	idx, err := os.OpenFile(indexPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := idx.Stat()
+	if err != nil {
+		idx.Close()
+		return nil, 0, err
+	}
+	entries := info.Size() / indexEntrySize
+	if entries == 0 {
+		return idx, -1, idx.Truncate(0)
+	}
+	var buf [indexEntrySize]byte
+	if _, err = idx.ReadAt(buf[:], (entries-1)*indexEntrySize); err != nil {
+		idx.Close()
+		return nil, 0, err
+	}
+	last := int64(binary.BigEndian.Uint64(buf[8:]))
+	if last > size {
+		return idx, -1, idx.Truncate(0)
+	}
+	// drop a partly written entry
+	return idx, last, idx.Truncate(entries * indexEntrySize)
+}
+
+// lastRotation approximates when app's current log was started by when its newest segment
+// was last written, so reopening the log does not restart its age.
+func (s *Store) lastRotation(app string) time.Time {
+	segments, err := s.segments(app)
+	if err != nil || len(segments) == 0 {
+		return time.Now()
+	}
+	info, err := os.Stat(segments[len(segments)-1])
+	if err != nil {
+		return time.Now()
+	}
+	return info.ModTime()
+}
+
+func (s *Store) closeLeastRecent() {
+	var oldest string
+	for app, f := range s.files {
+		if oldest == "" || f.lastWrite.Before(s.files[oldest].lastWrite) {
+			oldest = app
+		}
+	}
+	s.close(oldest)
+}
+
+func (s *Store) close(app string) {
+	if f, ok := s.files[app]; ok {
+		f.Close()
+		delete(s.files, app)
+	}
+}
+
+// rotate renames app's log to a segment, which is compressed and expired in the background.
+func (s *Store) rotate(app string) error {
+	s.close(app)
+	segment := s.segmentPath(app, time.Now())
+	if err := os.Rename(s.Path(app), segment); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	err := os.Rename(s.indexPath(s.Path(app)), s.indexPath(segment))
+	if err != nil && !os.IsNotExist(err) {
+		log.Println(err)
+	}
+	policy := s.policyFor(app)
+	s.enqueue(func() {
+		if err := compress(segment); err != nil {
+			log.Printf("could not compress %s: %v\n", segment, err)
+		}
+		s.expire(app, policy)
+	})
+	return nil
+}
+
+// segmentPath names a segment of app rotated at t, moving past the names of segments rotated
+// within the same millisecond so they are not overwritten.
+func (s *Store) segmentPath(app string, t time.Time) string {
+	for {
+		segment := s.Path(app) + "." + t.UTC().Format(segmentFormat)
+		_, err := os.Stat(segment)
+		_, gzErr := os.Stat(segment + ".gz")
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			return segment
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// Remove closes and deletes the log and segments of app.
+func (s *Store) Remove(app string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close(app)
+	s.removeSegment(s.Path(app))
+	s.enqueue(func() {
+		segments, _ := s.segments(app)
+		for _, segment := range segments {
+			s.removeSegment(segment)
+		}
+	})
+}
+
+// Close closes every log and waits for compression to finish. The store must not be written
+// to afterwards.
+func (s *Store) Close() {
+	close(s.stop)
+	<-s.stopped
+	s.mu.Lock()
+	for app := range s.files {
+		s.close(app)
+	}
+	for app, tails := range s.tails {
+		for c := range tails {
+			close(c)
+		}
+		delete(s.tails, app)
+	}
+	s.mu.Unlock()
+	s.jobsMu.Lock()
+	s.jobsClosed = true
+	s.jobsMu.Unlock()
+	s.signalJobs()
+	<-s.done
+}
+
+// enqueue queues job for the worker.
+func (s *Store) enqueue(job func()) {
+	s.jobsMu.Lock()
+	s.jobs = append(s.jobs, job)
+	s.jobsMu.Unlock()
+	s.signalJobs()
+}
+
+// signalJobs wakes the worker, unless it has yet to see an earlier signal.
+func (s *Store) signalJobs() {
+	select {
+	case s.jobsReady <- struct{}{}:
+	default:
+	}
+}
+
+// worker runs compression and expiry one job at a time, so they never race each other. It
+// returns once the store is closed and every queued job has run.
+func (s *Store) worker() {
+	defer close(s.done)
+	for {
+		s.jobsMu.Lock()
+		jobs, closed := s.jobs, s.jobsClosed
+		s.jobs = nil
+		s.jobsMu.Unlock()
+		for _, job := range jobs {
+			job()
+		}
+		if len(jobs) == 0 {
+			if closed {
+				return
+			}
+			<-s.jobsReady
+		}
+	}
+}
+
+func (s *Store) maintain() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(MaintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Maintain()
+		}
+	}
+}
+
+// Maintain rotates logs that have grown too old, drops handles of logs deleted or replaced
+// by someone else, and expires old segments. It runs every MaintenanceInterval.
+func (s *Store) Maintain() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for app, f := range s.files {
+		current, err := os.Stat(s.Path(app))
+		if info, statErr := f.Stat(); err != nil || statErr != nil || !os.SameFile(current, info) {
+			s.close(app)
+			// the offsets in its index belong to the old log
+			if err := os.Remove(s.indexPath(s.Path(app))); err != nil && !os.IsNotExist(err) {
+				log.Println(err)
+			}
+			continue
+		}
+		// the controller appends to logs as well
+		f.size = current.Size()
+		if p := s.policyFor(app); p.MaxAge > 0 && f.size > 0 && now.Sub(f.started) >= p.MaxAge {
+			if err := s.rotate(app); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	// one job expires every app, and is not queued again until it has started
+	if s.expiring {
+		return
+	}
+	s.expiring = true
+	policy, policies := s.policy, s.policies
+	s.enqueue(func() {
+		s.mu.Lock()
+		s.expiring = false
+		s.mu.Unlock()
+		s.expireAll(policy, policies)
+	})
+}
+
+// expireAll expires the segments of every app with policy, or its override in policies.
+func (s *Store) expireAll(policy Policy, policies map[string]Policy) {
+	apps, err := s.apps()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, app := range apps {
+		if p, ok := policies[app]; ok {
+			s.expire(app, p)
+		} else {
+			s.expire(app, policy)
+		}
+	}
+}
+
+// apps lists the apps with rotated segments.
+func (s *Store) apps() ([]string, error) {
+	matches, err := filepath.Glob(path.Join(s.root, "*.log.*"))
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var apps []string
+	for _, match := range matches {
+		name := path.Base(match)
+		name = name[:strings.Index(name, ".log.")]
+		if !seen[name] {
+			seen[name] = true
+			apps = append(apps, name)
+		}
+	}
+	return apps, nil
+}
+
+// segments lists the rotated segments of app, oldest first.
+func (s *Store) segments(app string) ([]string, error) {
+	matches, err := filepath.Glob(s.Path(app) + ".*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// expire deletes the segments of app the policy no longer keeps.
+func (s *Store) expire(app string, policy Policy) {
+	segments, err := s.segments(app)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	var keep []string
+	for _, segment := range segments {
+		info, err := os.Stat(segment)
+		if err != nil {
+			continue
+		}
+		if policy.MaxSegmentAge > 0 && time.Since(info.ModTime()) > policy.MaxSegmentAge {
+			s.removeSegment(segment)
+			continue
+		}
+		keep = append(keep, segment)
+	}
+	if policy.MaxSegments > 0 && len(keep) > policy.MaxSegments {
+		for _, segment := range keep[:len(keep)-policy.MaxSegments] {
+			s.removeSegment(segment)
+		}
+	}
+}
+
+// removeSegment deletes a log or segment and its index.
+func (s *Store) removeSegment(segment string) {
+	for _, p := range []string{segment, s.indexPath(segment)} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package logstore
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) (*Store, string) {
+	root, err := ioutil.TempDir("", "logstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, root
+}
+
+func readFile(t *testing.T, path string) string {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func readGzip(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func TestWrite(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+
+	s.Write("myapp", time.Now(), []byte("one"))
+	s.Write("myapp", time.Now(), []byte("two"))
+	if contents := readFile(t, s.Path("myapp")); contents != "one\ntwo\n" {
+		t.Errorf("unexpected log; got %q", contents)
+	}
+	if len(s.files) != 1 {
+		t.Errorf("expected the log to stay open; got %d open", len(s.files))
+	}
+}
+
+func TestMaxOpenFiles(t *testing.T) {
+	MaxOpenFiles = 2
+	defer func() { MaxOpenFiles = 128 }()
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+
+	for _, app := range []string{"a", "b", "a", "c"} {
+		s.Write(app, time.Now(), []byte(app))
+	}
+	if _, ok := s.files["b"]; ok || len(s.files) != 2 {
+		t.Errorf("expected the least recently written log to be closed; got %v", s.files)
+	}
+	if contents := readFile(t, s.Path("a")); contents != "a\na\n" {
+		t.Errorf("unexpected log; got %q", contents)
+	}
+}
+
+func TestRotateBySize(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	s.SetPolicy(Policy{MaxSize: 10, MaxSegments: 2}, nil)
+
+	for _, line := range []string{"first seg", "second seg", "third seg", "current"} {
+		s.Write("myapp", time.Now(), []byte(line))
+		// segments are named to the millisecond
+		time.Sleep(2 * time.Millisecond)
+	}
+	s.Close()
+
+	segments, _ := filepath.Glob(s.Path("myapp") + ".*")
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments to be kept; got %v", segments)
+	}
+	for i, expected := range []string{"second seg\n", "third seg\n"} {
+		if !strings.HasSuffix(segments[i], ".gz") {
+			t.Errorf("expected %s to be compressed", segments[i])
+			continue
+		}
+		if contents := readGzip(t, segments[i]); contents != expected {
+			t.Errorf("segment %d != %q; got %q", i, expected, contents)
+		}
+	}
+	if contents := readFile(t, s.Path("myapp")); contents != "current\n" {
+		t.Errorf("unexpected log; got %q", contents)
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+
+	old := filepath.Join(root, "gone.log.20150101T000000.000.gz")
+	ioutil.WriteFile(old, nil, 0644)
+	s.Write("myapp", time.Now(), []byte("old"))
+	s.Write("keep", time.Now(), []byte("kept"))
+	s.SetPolicy(Policy{MaxAge: time.Nanosecond, MaxSegmentAge: time.Hour},
+		map[string]Policy{"keep": {}})
+	os.Chtimes(old, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))
+	s.Maintain()
+	s.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expected the expired segment to be removed")
+	}
+	if segments, _ := filepath.Glob(s.Path("myapp") + ".*.gz"); len(segments) != 1 {
+		t.Errorf("expected myapp to be rotated by age; got %v", segments)
+	}
+	if segments, _ := filepath.Glob(s.Path("keep") + ".*"); len(segments) != 0 {
+		t.Errorf("expected the app policy to override; got %v", segments)
+	}
+}
+
+func TestMaintainReopensReplacedLogs(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+
+	s.Write("myapp", time.Now(), []byte("before"))
+	os.Remove(s.Path("myapp"))
+	s.Maintain()
+	s.Write("myapp", time.Now(), []byte("after"))
+	if contents := readFile(t, s.Path("myapp")); contents != "after\n" {
+		t.Errorf("unexpected log; got %q", contents)
+	}
+}
+
+func TestBusyWorkerDoesNotBlockWrites(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	s.SetPolicy(Policy{MaxSize: 1}, nil)
+	busy := make(chan bool)
+	s.enqueue(func() { <-busy })
+
+	written := make(chan bool)
+	go func() {
+		// every write rotates, queueing more jobs than the worker keeps up with
+		for i := 0; i < 200; i++ {
+			s.Write(fmt.Sprintf("app%d", i), time.Now(), []byte("line"))
+			s.Maintain()
+		}
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Error("expected writes to go on while the worker is busy")
+	}
+	close(busy)
+	s.Close()
+	if segments, _ := filepath.Glob(filepath.Join(root, "*.gz")); len(segments) != 200 {
+		t.Errorf("expected every segment to be compressed; got %d", len(segments))
+	}
+}
+
+func TestRemove(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	s.SetPolicy(Policy{MaxSize: 1}, nil)
+
+	s.Write("myapp", time.Now(), []byte("one"))
+	s.Write("myapp", time.Now(), []byte("two"))
+	s.Write("other", time.Now(), []byte("three"))
+	s.Remove("myapp")
+	s.Close()
+
+	if matches, _ := filepath.Glob(filepath.Join(root, "myapp.log*")); len(matches) != 0 {
+		t.Errorf("expected the logs of myapp to be removed; got %v", matches)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "other.log*")); len(matches) != 1 {
+		t.Errorf("expected the logs of other to be kept; got %v", matches)
+	}
+}
@@ -0,0 +1,192 @@
+package logstore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deis/deis/logger/syslog"
+)
+
+// ErrNotFound is returned when an app has no logs.
+var ErrNotFound = errors.New("no logs found")
+
+// Query selects lines from an app's logs. Zero fields do not filter.
+type Query struct {
+	// Lines limits the result to the newest matching lines.
+	Lines int
+	Since time.Time
+	Until time.Time
+	// Ps matches a process type such as web, or a single process such as web.1.
+	Ps string
+	// Source is deis for the events of the platform, or app for the output of the app.
+	Source string
+	Grep   *regexp.Regexp
+}
+
+// Match reports whether line passes every filter of q but Lines. A line of several lines is
+// matched by the header of its first.
+func (q Query) Match(line string) bool {
+	if q.Grep != nil && !q.Grep.MatchString(line) {
+		return false
+	}
+	if q.Ps == "" && q.Source == "" && q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	header := line
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		header = line[:i]
+	}
+	m := syslog.Parse([]byte(header))
+	if q.Ps != "" && m.ProcID != q.Ps && !strings.HasPrefix(m.ProcID, q.Ps+".") {
+		return false
+	}
+	if q.Source != "" && (q.Source == "deis") != (m.AppName == "deis") {
+		return false
+	}
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	return !m.Timestamp.IsZero() && !m.Timestamp.Before(q.Since) &&
+		(q.Until.IsZero() || !m.Timestamp.After(q.Until))
+}
+
+// source is a log or segment with its index.
+type source struct {
+	path    string
+	entries []indexEntry
+}
+
+// start returns the time of the first line indexed, or zero if there is none.
+func (src source) start() time.Time {
+	if len(src.entries) == 0 {
+		return time.Time{}
+	}
+	return src.entries[0].time
+}
+
+// Query returns the lines of app's logs matching q, oldest first. Segments outside the time
+// range of q are skipped, as are the parts of a segment its index shows are.
+func (s *Store) Query(app string, q Query) ([]string, error) {
+	sources, err := s.sources(app)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var lines []string
+	// newest first, so a query for the newest lines stops as soon as it has them
+	for i := len(sources) - 1; i >= 0; i-- {
+		if start := sources[i].start(); !q.Until.IsZero() && start.After(q.Until) {
+			continue
+		}
+		limit := 0
+		if q.Lines > 0 {
+			limit = q.Lines - len(lines)
+		}
+		matched, err := sources[i].scan(q, limit)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(matched, lines...)
+		if q.Lines > 0 && len(lines) >= q.Lines {
+			break
+		}
+		// older segments end before this one starts
+		if start := sources[i].start(); !q.Since.IsZero() && !start.IsZero() &&
+			!start.After(q.Since) {
+			break
+		}
+	}
+	return lines, nil
+}
+
+// sources lists the segments and the current log of app, oldest first.
+func (s *Store) sources(app string) ([]source, error) {
+	segments, err := s.segments(app)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(s.Path(app)); err == nil {
+		segments = append(segments, s.Path(app))
+	}
+	var sources []source
+	for i, segment := range segments {
+		// skip the compressed copy of a segment still being compressed
+		if i > 0 && segment == segments[i-1]+".gz" {
+			continue
+		}
+		entries, err := readIndex(s.indexPath(segment))
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source{path: segment, entries: entries})
+	}
+	return sources, nil
+}
+
+// scan returns the lines of src matching q. A limit above zero keeps only the newest lines.
+func (src source) scan(q Query, limit int) ([]string, error) {
+	f, err := os.Open(src.path)
+	if err != nil {
+		// rotated or expired since it was listed
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	start, end := seekRange(src.entries, q.Since, q.Until)
+	var r io.Reader = f
+	if strings.HasSuffix(src.path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		if _, err = io.CopyN(ioutil.Discard, gz, start); err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+		r = gz
+	} else if _, err = f.Seek(start, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+	if end >= 0 {
+		r = io.LimitReader(r, end-start)
+	}
+
+	var lines []string
+	br := bufio.NewReaderSize(r, 65536)
+	for {
+		line, err := br.ReadString('\n')
+		line = unescapeNewlines(strings.TrimSuffix(line, "\n"))
+		if line != "" && q.Match(line) {
+			lines = append(lines, line)
+			if limit > 0 && len(lines) > 2*limit {
+				lines = append(lines[:0], lines[len(lines)-limit:]...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return lines, nil
+}
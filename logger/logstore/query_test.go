@@ -0,0 +1,167 @@
+package logstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	dtime "github.com/deis/deis/pkg/time"
+)
+
+var queryStart = time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// writeLines logs a line from web.1 and one from worker.1 every minute from queryStart.
+func writeLines(s *Store, minutes int) {
+	for i := 0; i < minutes; i++ {
+		t := queryStart.Add(time.Duration(i) * time.Minute)
+		for _, ps := range []string{"web.1", "worker.1"} {
+			s.Write("myapp", t, []byte(fmt.Sprintf("%s myapp[%s]: line %d", t.Format(dtime.DeisDatetimeFormat), ps, i)))
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+	writeLines(s, 3)
+
+	tests := []struct {
+		q        Query
+		expected []string
+	}{
+		{Query{Lines: 1}, []string{"2015-06-01T12:02:00UTC myapp[worker.1]: line 2"}},
+		{Query{Ps: "web"}, []string{
+			"2015-06-01T12:00:00UTC myapp[web.1]: line 0",
+			"2015-06-01T12:01:00UTC myapp[web.1]: line 1",
+			"2015-06-01T12:02:00UTC myapp[web.1]: line 2",
+		}},
+		{Query{Ps: "web.1", Since: queryStart.Add(time.Minute)}, []string{
+			"2015-06-01T12:01:00UTC myapp[web.1]: line 1",
+			"2015-06-01T12:02:00UTC myapp[web.1]: line 2",
+		}},
+		{Query{Until: queryStart, Grep: regexp.MustCompile(`worker`)}, []string{
+			"2015-06-01T12:00:00UTC myapp[worker.1]: line 0",
+		}},
+		{Query{Ps: "we"}, nil},
+	}
+	for _, tt := range tests {
+		lines, err := s.Query("myapp", tt.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lines, tt.expected) {
+			t.Errorf("query %+v\nexpected %q\ngot      %q", tt.q, tt.expected, lines)
+		}
+	}
+
+	if _, err := s.Query("other", Query{}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound for an app without logs; got %v", err)
+	}
+}
+
+func TestQueryMultilineEvent(t *testing.T) {
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+	writeLines(s, 1)
+	event := "2015-06-01T12:01:00UTC myapp[web.1]: Exception in thread \"main\"\n\tat Main.main(Main.java:3)"
+	s.Write("myapp", queryStart.Add(time.Minute), []byte(event+"\n"))
+	live, cancel := s.Tail("myapp")
+	defer cancel()
+	s.Write("myapp", queryStart.Add(2*time.Minute), []byte(event))
+
+	tests := []struct {
+		q        Query
+		expected []string
+	}{
+		{Query{Lines: 1}, []string{event}},
+		{Query{Ps: "web", Since: queryStart.Add(time.Minute)}, []string{event, event}},
+		{Query{Grep: regexp.MustCompile(`Main\.main`)}, []string{event, event}},
+	}
+	for _, tt := range tests {
+		lines, err := s.Query("myapp", tt.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lines, tt.expected) {
+			t.Errorf("query %+v\nexpected %q\ngot      %q", tt.q, tt.expected, lines)
+		}
+	}
+	if line := <-live; line != event {
+		t.Errorf("expected the tail to get the whole event; got %q", line)
+	}
+
+	// the event is stored as one line, so line based readers of the log keep it whole
+	contents, err := ioutil.ReadFile(s.Path("myapp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n"); len(lines) != 4 {
+		t.Errorf("expected 4 stored lines; got %q", lines)
+	}
+}
+
+func TestQueryAcrossSegments(t *testing.T) {
+	IndexInterval = 100
+	defer func() { IndexInterval = 64 * 1024 }()
+	s, root := newTestStore(t)
+	defer os.RemoveAll(root)
+	defer s.Close()
+	s.SetPolicy(Policy{MaxSize: 1000}, nil)
+	writeLines(s, 30)
+	// wait for the segments to be compressed
+	compressed := make(chan bool)
+	s.enqueue(func() { close(compressed) })
+	<-compressed
+
+	segments, _ := s.segments("myapp")
+	if len(segments) < 2 {
+		t.Fatalf("expected the log to be rotated; got %v", segments)
+	}
+	lines, err := s.Query("myapp", Query{Ps: "web", Since: queryStart.Add(10 * time.Minute),
+		Until: queryStart.Add(20 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 11 || lines[0] != "2015-06-01T12:10:00UTC myapp[web.1]: line 10" ||
+		lines[10] != "2015-06-01T12:20:00UTC myapp[web.1]: line 20" {
+		t.Errorf("unexpected lines; got %q", lines)
+	}
+
+	lines, err = s.Query("myapp", Query{Lines: 45})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 45 || lines[0] != "2015-06-01T12:07:00UTC myapp[worker.1]: line 7" {
+		t.Errorf("unexpected lines; got %d starting with %q", len(lines), lines[0])
+	}
+}
+
+func TestSeekRange(t *testing.T) {
+	entries := []indexEntry{
+		{queryStart, 0},
+		{queryStart.Add(time.Minute), 100},
+		{queryStart.Add(2 * time.Minute), 200},
+	}
+	tests := []struct {
+		since, until time.Time
+		start, end   int64
+	}{
+		{time.Time{}, time.Time{}, 0, -1},
+		{queryStart.Add(90 * time.Second), time.Time{}, 100, -1},
+		{queryStart.Add(time.Minute), queryStart.Add(time.Minute), 0, 200},
+		{time.Time{}, queryStart.Add(-time.Minute), 0, 0},
+	}
+	for _, tt := range tests {
+		if start, end := seekRange(entries, tt.since, tt.until); start != tt.start || end != tt.end {
+			t.Errorf("seekRange(%v, %v) = %d, %d; expected %d, %d", tt.since, tt.until,
+				start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package logstore
+
+// TailBuffer is the number of lines a tail holds for a slow reader. Lines arriving while it is
+// full are skipped.
+var TailBuffer = 256
+
+// Tail returns the lines written to app's log from now on, until cancel is called.
+func (s *Store) Tail(app string) (lines <-chan string, cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := make(chan string, TailBuffer)
+	if s.tails[app] == nil {
+		s.tails[app] = make(map[chan string]bool)
+	}
+	s.tails[app][c] = true
+	return c, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.tails[app][c] {
+			delete(s.tails[app], c)
+			if len(s.tails[app]) == 0 {
+				delete(s.tails, app)
+			}
+			close(c)
+		}
+	}
+}
+
+// publish passes line to the tails of app. It must be called with s.mu held.
+func (s *Store) publish(app, line string) {
+	for c := range s.tails[app] {
+		select {
+		case c <- line:
+		default:
+		}
+	}
+}
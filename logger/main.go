@@ -1,23 +1,39 @@
 package main
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"path"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/coreos/go-etcd/etcd"
+	"github.com/deis/deis/logger/drain"
+	"github.com/deis/deis/logger/logstore"
 	"github.com/deis/deis/logger/syslogd"
 )
 
+const etcdKeyNotFound = 100
+
 var (
 	logAddr         string
+	logRoot         string
 	logPort         int
+	logProtocol     string
+	logTLSPort      int
+	apiPort         int
+	apiToken        string
+	metricsPort     int
+	queueSpillSize  string
 	drainURI        string
 	enablePublish   bool
 	publishHost     string
@@ -30,8 +46,18 @@ var (
 func init() {
 	flag.StringVar(&logAddr, "log-addr", "0.0.0.0", "bind address for the logger")
 	flag.IntVar(&logPort, "log-port", 514, "bind port for the logger")
+	flag.StringVar(&logProtocol, "log-protocol", "udp", "comma separated protocols to receive logs with: udp, tcp and tls")
+	flag.IntVar(&logTLSPort, "log-tls-port", 6514, "bind port for the tls protocol")
+	flag.StringVar(&syslogd.TLSCertFile, "log-tls-cert", "/etc/ssl/deis/logger.crt", "certificate file for the tls protocol")
+	flag.StringVar(&syslogd.TLSKeyFile, "log-tls-key", "/etc/ssl/deis/logger.key", "key file for the tls protocol")
+	flag.IntVar(&apiPort, "api-port", 8088, "bind port for the log query API, 0 to disable")
+	flag.StringVar(&apiToken, "api-token", getopt("API_TOKEN", ""), "token the log query API requires, read from or generated in etcd if not set")
+	flag.IntVar(&metricsPort, "metrics-port", 0, "bind port for the metrics endpoint if not the api port")
+	flag.IntVar(&syslogd.QueueSize, "queue-size", 1024, "number of messages queued in memory before they overflow")
+	flag.StringVar(&syslogd.QueueOverflow, "queue-overflow", "drop", "what to do with messages overflowing the queue: drop, block or spill to disk")
+	flag.StringVar(&queueSpillSize, "queue-spill-size", "100MB", "maximum size of the spilled messages")
 	flag.StringVar(&drainURI, "drain-uri", "", "default drainURI, once set in etcd, this has no effect.")
-	flag.StringVar(&syslogd.LogRoot, "log-root", "/data/logs", "log path to store logs")
+	flag.StringVar(&logRoot, "log-root", "/data/logs", "log path to store logs")
 	flag.BoolVar(&enablePublish, "enable-publish", false, "enable publishing to service discovery")
 	flag.StringVar(&publishHost, "publish-host", getopt("HOST", "127.0.0.1"), "service discovery hostname")
 	flag.IntVar(&publishInterval, "publish-interval", 10, "publish interval in seconds")
@@ -46,7 +72,7 @@ func main() {
 	client := etcd.NewClient([]string{"http://" + publishHost + ":" + publishPort})
 	ticker := time.NewTicker(time.Duration(publishInterval) * time.Second)
 	signalChan := make(chan os.Signal, 1)
-	drainChan := make(chan string)
+	drainChan := make(chan []drain.Config)
 	exitChan := make(chan bool)
 	cleanupChan := make(chan bool)
 	signal.Notify(signalChan, syscall.SIGTERM, syscall.SIGINT)
@@ -56,42 +82,219 @@ func main() {
 		setEtcd(client, publishPath+"/drain", drainURI, 0)
 	}
 
-	go syslogd.Listen(exitChan, cleanupChan, drainChan, fmt.Sprintf("%s:%d", logAddr, logPort))
+	listeners, err := parseListeners(logProtocol)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := logstore.New(logRoot)
+	if err != nil {
+		log.Fatalf("unable to create LogRoot at %s: %v", logRoot, err)
+	}
+	syslogd.SpillPath = path.Join(logRoot, ".spill")
+	if syslogd.SpillSize, err = parseSize(queueSpillSize); err != nil {
+		log.Fatal(err)
+	}
+	// apps seen in etcd, whose logs are removed once they are destroyed
+	apps := newAppTracker()
+
+	// drains are read whenever they change, and again on the next tick if that fails
+	drainsChanged := make(chan bool, 1)
+	drainsStale := false
+	stopWatches := make(chan bool)
+	for _, key := range []string{publishPath + "/drain", publishPath + "/drains"} {
+		go watchKey(etcd.NewClient(client.GetCluster()), key, drainsChanged, stopWatches)
+	}
+
+	go syslogd.Listen(exitChan, cleanupChan, drainChan, store, listeners)
+	// the port the log query API is served on, published for the controller
+	servedAPIPort := 0
+	if apiPort > 0 {
+		token := apiToken
+		if token == "" {
+			token, err = getAPIToken(client, publishPath)
+		}
+		if err != nil {
+			log.Printf("warning: not serving the log query API without a token: %v\n", err)
+		} else {
+			go serveAPI(store, token, fmt.Sprintf("%s:%d", logAddr, apiPort))
+			servedAPIPort = apiPort
+		}
+	}
+	if metricsPort > 0 && metricsPort != apiPort {
+		go serveMetricsOn(fmt.Sprintf("%s:%d", logAddr, metricsPort))
+	}
 	if enablePublish {
-		publishKeys(client, publishHost, publishPath, strconv.Itoa(logPort), uint64(time.Duration(publishTTL)*time.Second))
+		publishKeys(client, publishHost, publishPath, strconv.Itoa(logPort), servedAPIPort, uint64(time.Duration(publishTTL)*time.Second))
 	}
 
 	for {
 		select {
 		case <-ticker.C:
 			if enablePublish {
-				publishKeys(client, publishHost, publishPath, strconv.Itoa(logPort), uint64(time.Duration(publishTTL)*time.Second))
+				publishKeys(client, publishHost, publishPath, strconv.Itoa(logPort), servedAPIPort, uint64(time.Duration(publishTTL)*time.Second))
 			}
-			// HACK (bacongobbler): poll etcd every publishInterval for changes in the log drain value.
-			// etcd's .Watch() implementation is broken when you use TTLs
-			//
-			// https://github.com/coreos/etcd/issues/2679
-			resp, err := client.Get(publishPath+"/drain", false, false)
-			if err != nil {
-				log.Printf("warning: could not retrieve drain URI from etcd: %v\n", err)
-				continue
+			if policy, apps, err := getPolicy(client, publishPath); err == nil {
+				store.SetPolicy(policy, apps)
+			} else {
+				log.Printf("warning: could not retrieve log retention from etcd: %v\n", err)
 			}
-			if resp != nil && resp.Node != nil {
-				drainChan <- resp.Node.Value
+			if current, err := getApps(client); err == nil {
+				apps.update(store, current)
+			} else {
+				log.Printf("warning: could not retrieve apps from etcd: %v\n", err)
 			}
+			if drainsStale {
+				notify(drainsChanged)
+			}
+		case <-drainsChanged:
+			configs, err := getDrains(client, publishPath)
+			if drainsStale = err != nil; drainsStale {
+				log.Printf("warning: could not retrieve drains from etcd: %v\n", err)
+				continue
+			}
+			drainChan <- configs
 		case <-signalChan:
 			close(exitChan)
 		case <-cleanupChan:
 			ticker.Stop()
+			close(stopWatches)
+			store.Close()
 			return
 		}
 	}
 }
 
-// publishKeys sets relevant etcd keys with a time-to-live.
-func publishKeys(client *etcd.Client, host, etcdPath, port string, ttl uint64) {
+// parseListeners turns the --log-protocol flag into listeners. udp and tcp share --log-port,
+// tls uses --log-tls-port.
+func parseListeners(protocols string) ([]syslogd.Listener, error) {
+	var listeners []syslogd.Listener
+	seen := make(map[string]bool)
+	for _, protocol := range strings.Split(protocols, ",") {
+		protocol = strings.TrimSpace(protocol)
+		port := logPort
+		switch protocol {
+		case "udp", "tcp":
+		case "tls":
+			port = logTLSPort
+		default:
+			return nil, fmt.Errorf("unknown log protocol %q, use udp, tcp or tls", protocol)
+		}
+		if seen[protocol] {
+			continue
+		}
+		seen[protocol] = true
+		listeners = append(listeners, syslogd.Listener{
+			Protocol: protocol,
+			Addr:     fmt.Sprintf("%s:%d", logAddr, port),
+		})
+	}
+	return listeners, nil
+}
+
+// getDrains reads the drain at etcdPath/drain, which takes every app's logs, and the drains
+// under etcdPath/drains. Each of those is either a URL or a directory holding a url and
+// optionally the app whose logs it takes.
+func getDrains(client *etcd.Client, etcdPath string) ([]drain.Config, error) {
+	var configs []drain.Config
+	resp, err := client.Get(etcdPath+"/drain", false, false)
+	if err != nil && !isKeyNotFound(err) {
+		return nil, err
+	}
+	if err == nil && resp.Node.Value != "" {
+		configs = append(configs, drain.Config{ID: "drain", URL: resp.Node.Value})
+	}
+
+	resp, err = client.Get(etcdPath+"/drains", true, true)
+	if err != nil {
+		if isKeyNotFound(err) {
+			return configs, nil
+		}
+		return nil, err
+	}
+	for _, node := range resp.Node.Nodes {
+		c := drain.Config{ID: path.Base(node.Key), URL: node.Value}
+		for _, child := range node.Nodes {
+			switch path.Base(child.Key) {
+			case "url":
+				c.URL = child.Value
+			case "app":
+				c.App = child.Value
+			}
+		}
+		if c.URL == "" {
+			log.Printf("warning: drain %s has no url\n", c.ID)
+			continue
+		}
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
+
+func isKeyNotFound(err error) bool {
+	e, ok := err.(*etcd.EtcdError)
+	return ok && e.ErrorCode == etcdKeyNotFound
+}
+
+// serveAPI serves the log query API of store on addr to clients sending token, along with the
+// metrics of the logger unless they have a port of their own.
+func serveAPI(store *logstore.Store, token, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/logs/", requireToken(token, logstore.NewHandler(store)))
+	if metricsPort == 0 || metricsPort == apiPort {
+		mux.HandleFunc("/metrics", serveMetrics)
+	}
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+
+// requireToken passes requests with an "Authorization: token <token>" header to h, and
+// refuses the others.
+func requireToken(token string, h http.Handler) http.Handler {
+	expected := []byte("token " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "token")
+			http.Error(w, "a valid token is required", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// getAPIToken reads the token of the log query API from etcdPath/apiToken. If there is none,
+// it generates one there, so every logger and its clients share it.
+func getAPIToken(client *etcd.Client, etcdPath string) (string, error) {
+	key := etcdPath + "/apiToken"
+	resp, err := client.Get(key, false, false)
+	if err == nil && resp.Node.Value != "" {
+		return resp.Node.Value, nil
+	}
+	if err != nil && !isKeyNotFound(err) {
+		return "", err
+	}
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if _, err = client.Create(key, token, 0); err != nil {
+		// another logger may have created it first
+		if resp, getErr := client.Get(key, false, false); getErr == nil && resp.Node.Value != "" {
+			return resp.Node.Value, nil
+		}
+		return "", err
+	}
+	return token, nil
+}
+
+// publishKeys sets relevant etcd keys with a time-to-live. The port of the log query API is
+// published only if it is served.
+func publishKeys(client *etcd.Client, host, etcdPath, port string, apiPort int, ttl uint64) {
 	setEtcd(client, etcdPath+"/host", host, ttl)
 	setEtcd(client, etcdPath+"/port", port, ttl)
+	if apiPort > 0 {
+		setEtcd(client, etcdPath+"/apiPort", strconv.Itoa(apiPort), ttl)
+	}
 }
 
 func setEtcd(client *etcd.Client, key, value string, ttl uint64) {
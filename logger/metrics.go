@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/deis/deis/logger/syslogd"
+)
+
+// serveMetricsOn serves the metrics of the logger alone on addr.
+func serveMetricsOn(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", serveMetrics)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+
+// serveMetrics writes the metrics of the logger in the Prometheus text format.
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w, syslogd.GetStats())
+}
+
+// metric is a metric family, with a sample for each set of labels.
+type metric struct {
+	name, kind, help string
+	samples          []sample
+}
+
+type sample struct {
+	labels string
+	value  interface{}
+}
+
+func single(value interface{}) []sample {
+	return []sample{{value: value}}
+}
+
+func writeMetrics(w io.Writer, stats syslogd.Stats) {
+	received := metric{"deis_logger_messages_received_total", "counter",
+		"Messages received for each app.", nil}
+	apps := make([]string, 0, len(stats.Received))
+	for app := range stats.Received {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	for _, app := range apps {
+		received.samples = append(received.samples,
+			sample{labels("app", app), stats.Received[app]})
+	}
+
+	delivered := metric{"deis_logger_drain_delivered_total", "counter",
+		"Messages delivered by each drain.", nil}
+	dropped := metric{"deis_logger_drain_dropped_total", "counter",
+		"Messages dropped by each drain because its buffer was full.", nil}
+	errors := metric{"deis_logger_drain_errors_total", "counter",
+		"Failed attempts of each drain to connect or send.", nil}
+	for _, d := range stats.Drains {
+		l := labels("drain", d.ID, "app", d.App)
+		delivered.samples = append(delivered.samples, sample{l, d.Delivered})
+		dropped.samples = append(dropped.samples, sample{l, d.Dropped})
+		errors.samples = append(errors.samples, sample{l, d.Errors})
+	}
+
+	for _, m := range []metric{
+		received,
+		{"deis_logger_unknown_app_total", "counter",
+			"Messages no app name could be found in.", single(stats.UnknownApp)},
+		{"deis_logger_bytes_written_total", "counter",
+			"Bytes written to app logs.", single(stats.BytesWritten)},
+		{"deis_logger_write_errors_total", "counter",
+			"Failed writes to app logs.", single(stats.WriteErrors)},
+		{"deis_logger_queue_depth", "gauge",
+			"Messages waiting in the queue.", single(stats.Queue.Depth)},
+		{"deis_logger_queue_capacity", "gauge",
+			"Messages the queue holds in memory.", single(stats.Queue.Capacity)},
+		{"deis_logger_queue_dropped_total", "counter",
+			"Messages dropped because the queue was full.", single(stats.Queue.Dropped)},
+		{"deis_logger_queue_spilled_total", "counter",
+			"Messages spilled to disk because the queue was full.", single(stats.Queue.Spilled)},
+		{"deis_logger_queue_spill_bytes", "gauge",
+			"Bytes of spilled messages waiting on disk.", single(stats.Queue.SpillBytes)},
+		delivered,
+		dropped,
+		errors,
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, s := range m.samples {
+			fmt.Fprintf(w, "%s%s %v\n", m.name, s.labels, s.value)
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats pairs of label names and values.
+func labels(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/deis/deis/logger/drain"
+	"github.com/deis/deis/logger/syslog"
+	"github.com/deis/deis/logger/syslogd"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	writeMetrics(&buf, syslogd.Stats{
+		Received:     map[string]uint64{"myapp": 3, "other": 1},
+		UnknownApp:   2,
+		BytesWritten: 120,
+		Queue:        syslog.QueueStats{Depth: 4, Capacity: 1024, Dropped: 5},
+		Drains:       []drain.Stats{{ID: "papertrail", Delivered: 4, Errors: 1}},
+	})
+	for _, expected := range []string{
+		"# TYPE deis_logger_messages_received_total counter\n" +
+			"deis_logger_messages_received_total{app=\"myapp\"} 3\n" +
+			"deis_logger_messages_received_total{app=\"other\"} 1\n",
+		"deis_logger_unknown_app_total 2\n",
+		"deis_logger_bytes_written_total 120\n",
+		"# TYPE deis_logger_queue_depth gauge\ndeis_logger_queue_depth 4\n",
+		"deis_logger_queue_dropped_total 5\n",
+		"deis_logger_drain_errors_total{drain=\"papertrail\",app=\"\"} 1\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in the metrics; got %s", expected, buf.String())
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	if l := labels("drain", `a"b\c`, "app", "myapp"); l != `{drain="a\"b\\c",app="myapp"}` {
+		t.Errorf("unexpected labels; got %s", l)
+	}
+}
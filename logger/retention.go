@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-etcd/etcd"
+	"github.com/deis/deis/logger/logstore"
+)
+
+// servicesPath is where the controller keeps a directory for every app.
+const servicesPath = "/deis/services"
+
+// getPolicy reads the retention policy from the rotateSize, rotateAge, retainSegments and
+// retainAge keys under etcdPath, and the overrides of single apps from the same keys under
+// etcdPath/apps/<app>. Keys that are missing keep their defaults.
+func getPolicy(client *etcd.Client, etcdPath string) (logstore.Policy, map[string]logstore.Policy, error) {
+	policy := logstore.DefaultPolicy
+	resp, err := client.Get(etcdPath, false, true)
+	if err != nil {
+		if isKeyNotFound(err) {
+			return policy, nil, nil
+		}
+		return policy, nil, err
+	}
+
+	var appNodes etcd.Nodes
+	for _, node := range resp.Node.Nodes {
+		if path.Base(node.Key) == "apps" {
+			appNodes = node.Nodes
+		}
+	}
+	policy = parsePolicy(policy, resp.Node.Nodes)
+	apps := make(map[string]logstore.Policy)
+	for _, node := range appNodes {
+		apps[path.Base(node.Key)] = parsePolicy(policy, node.Nodes)
+	}
+	return policy, apps, nil
+}
+
+// parsePolicy overrides base with the policy keys among nodes. Invalid values are logged
+// and ignored.
+func parsePolicy(base logstore.Policy, nodes etcd.Nodes) logstore.Policy {
+	policy := base
+	for _, node := range nodes {
+		var err error
+		switch path.Base(node.Key) {
+		case "rotateSize":
+			var size int64
+			if size, err = parseSize(node.Value); err == nil {
+				policy.MaxSize = size
+			}
+		case "rotateAge":
+			var age time.Duration
+			if age, err = time.ParseDuration(node.Value); err == nil {
+				policy.MaxAge = age
+			}
+		case "retainSegments":
+			var segments int
+			if segments, err = strconv.Atoi(node.Value); err == nil {
+				policy.MaxSegments = segments
+			}
+		case "retainAge":
+			var age time.Duration
+			if age, err = time.ParseDuration(node.Value); err == nil {
+				policy.MaxSegmentAge = age
+			}
+		}
+		if err != nil {
+			log.Printf("warning: invalid %s %q: %v\n", node.Key, node.Value, err)
+		}
+	}
+	return policy
+}
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30}, {"G", 1 << 30},
+	{"MB", 1 << 20}, {"M", 1 << 20},
+	{"KB", 1 << 10}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a number of bytes with an optional unit, such as 100MB.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s is not a size, use a number of bytes such as 100MB", s)
+	}
+	return n * unit, nil
+}
+
+// getApps lists the apps the controller knows about. A missing services directory is an error
+// rather than no apps, as it says nothing about which apps were destroyed.
+func getApps(client *etcd.Client) (map[string]bool, error) {
+	resp, err := client.Get(servicesPath, false, false)
+	if err != nil {
+		return nil, err
+	}
+	apps := make(map[string]bool)
+	for _, node := range resp.Node.Nodes {
+		apps[path.Base(node.Key)] = true
+	}
+	return apps, nil
+}
+
+// appTracker follows the apps listed by the controller, to delete the logs of those destroyed.
+type appTracker struct {
+	known   map[string]bool
+	missing map[string]bool
+}
+
+func newAppTracker() *appTracker {
+	return &appTracker{known: make(map[string]bool), missing: make(map[string]bool)}
+}
+
+// update deletes the logs of known apps missing from apps on this and the previous update, so
+// an app briefly missing, as while etcd is restored, keeps its logs.
+func (t *appTracker) update(store *logstore.Store, apps map[string]bool) {
+	for app := range t.known {
+		if apps[app] {
+			delete(t.missing, app)
+			continue
+		}
+		if !t.missing[app] {
+			t.missing[app] = true
+			continue
+		}
+		log.Printf("removing the logs of destroyed app %s\n", app)
+		store.Remove(app)
+		delete(t.known, app)
+		delete(t.missing, app)
+	}
+	for app := range apps {
+		t.known[app] = true
+	}
+}
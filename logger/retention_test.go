@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-etcd/etcd"
+	"github.com/deis/deis/logger/logstore"
+)
+
+func TestParseSize(t *testing.T) {
+	sizes := map[string]int64{"1024": 1024, "10MB": 10 << 20, "2g": 2 << 30, "512 KB": 512 << 10}
+	for s, expected := range sizes {
+		if size, err := parseSize(s); err != nil || size != expected {
+			t.Errorf("parseSize(%q) != %d; got %d, %v", s, expected, size, err)
+		}
+	}
+	for _, s := range []string{"", "MB", "-1", "ten"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	base := logstore.Policy{MaxSize: 1, MaxAge: time.Hour, MaxSegments: 3}
+	nodes := etcd.Nodes{
+		&etcd.Node{Key: "/deis/logs/rotateSize", Value: "10MB"},
+		&etcd.Node{Key: "/deis/logs/retainSegments", Value: "lots"},
+		&etcd.Node{Key: "/deis/logs/retainAge", Value: "168h"},
+		&etcd.Node{Key: "/deis/logs/host", Value: "10.0.0.1"},
+	}
+	expected := logstore.Policy{MaxSize: 10 << 20, MaxAge: time.Hour, MaxSegments: 3,
+		MaxSegmentAge: 168 * time.Hour}
+	if policy := parsePolicy(base, nodes); policy != expected {
+		t.Errorf("policy != %+v; got %+v", expected, policy)
+	}
+}
+
+func TestAppTracker(t *testing.T) {
+	root, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	store, err := logstore.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Write("kept", time.Now(), []byte("kept"))
+	store.Write("destroyed", time.Now(), []byte("destroyed"))
+
+	tracker := newAppTracker()
+	tracker.update(store, map[string]bool{"kept": true, "destroyed": true})
+	// an app missing from a single poll keeps its logs
+	tracker.update(store, map[string]bool{"kept": true})
+	tracker.update(store, map[string]bool{"kept": true, "destroyed": true})
+	if _, err := os.Stat(store.Path("destroyed")); err != nil {
+		t.Errorf("expected the logs of an app missing once to be kept; got %v", err)
+	}
+	tracker.update(store, map[string]bool{"kept": true})
+	tracker.update(store, map[string]bool{"kept": true})
+	store.Close()
+	if _, err := os.Stat(store.Path("destroyed")); !os.IsNotExist(err) {
+		t.Errorf("expected the logs of a destroyed app to be removed; got %v", err)
+	}
+	if _, err := os.Stat(store.Path("kept")); err != nil {
+		t.Errorf("expected the logs of a listed app to be kept; got %v", err)
+	}
+}
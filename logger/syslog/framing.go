@@ -0,0 +1,102 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// maxMessageSize is the largest message the server keeps, the same size as the
+// packet buffer and as logspout's. Longer messages are truncated.
+const maxMessageSize = 1048576
+
+// maxMsgLenDigits bounds the MSG-LEN of an octet counted frame.
+const maxMsgLenDigits = 10
+
+var errBadFrame = errors.New("invalid octet counted syslog frame")
+
+// readFrame reads one message from a stream framed as in RFC 6587. A frame
+// starting with "MSG-LEN SP" uses octet counting, anything else uses
+// non-transparent framing and ends with a newline.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.Peek(1); err != nil {
+		return nil, err
+	}
+	if isOctetCounted(r) {
+		return readOctetCounted(r)
+	}
+	return readLine(r)
+}
+
+// isOctetCounted looks for a MSG-LEN, NONZERO-DIGIT *DIGIT, followed by a space.
+// Messages without a PRI, like the ones logspout sends, may start with digits
+// too, so a leading digit alone is not enough. Bytes are peeked one at a time
+// so a short frame is not held back waiting for more data.
+func isOctetCounted(r *bufio.Reader) bool {
+	for i := 1; i <= maxMsgLenDigits+1; i++ {
+		b, err := r.Peek(i)
+		if err != nil {
+			return false
+		}
+		c := b[i-1]
+		switch {
+		case c == ' ':
+			return i > 1
+		case c < '0' || c > '9', i == 1 && c == '0':
+			return false
+		}
+	}
+	return false
+}
+
+func readOctetCounted(r *bufio.Reader) ([]byte, error) {
+	digits, err := r.ReadSlice(' ')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.ParseInt(string(digits[:len(digits)-1]), 10, 64)
+	if err != nil {
+		return nil, errBadFrame
+	}
+
+	size := n
+	if size > maxMessageSize {
+		size = maxMessageSize
+	}
+	msg := make([]byte, size)
+	if _, err = io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	if _, err = io.CopyN(ioutil.Discard, r, n-size); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var msg []byte
+	for {
+		line, err := r.ReadSlice('\n')
+		if len(msg) < maxMessageSize {
+			if room := maxMessageSize - len(msg); len(line) > room {
+				msg = append(msg, line[:room]...)
+			} else {
+				msg = append(msg, line...)
+			}
+		}
+		switch err {
+		case nil:
+			return msg, nil
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			// the last message of a stream needs no trailing newline
+			if len(msg) > 0 {
+				return msg, nil
+			}
+		}
+		return nil, err
+	}
+}
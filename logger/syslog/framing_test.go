@@ -0,0 +1,55 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadFrame(t *testing.T) {
+	stream := "11 <14>1 - - -14 <14>1 - - - -\n" +
+		"2015-06-15T18:40:09UTC myapp[web.1]: listening on 5000\n" +
+		"<14>no trailing newline"
+	expected := []string{
+		"<14>1 - - -",
+		"<14>1 - - - -\n",
+		"2015-06-15T18:40:09UTC myapp[web.1]: listening on 5000\n",
+		"<14>no trailing newline",
+	}
+	r := bufio.NewReader(strings.NewReader(stream))
+	for _, e := range expected {
+		frame, err := readFrame(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(frame) != e {
+			t.Errorf("frame != %q; got %q", e, frame)
+		}
+	}
+	if _, err := readFrame(r); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestReadFrameTruncates(t *testing.T) {
+	long := strings.Repeat("a", maxMessageSize+10)
+	stream := long + "\n" + "1048586 " + long + "next\n"
+	r := bufio.NewReaderSize(strings.NewReader(stream), 65536)
+	for i := 0; i < 2; i++ {
+		frame, err := readFrame(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(frame) != maxMessageSize {
+			t.Errorf("frame %d should be truncated to %d bytes; got %d", i, maxMessageSize, len(frame))
+		}
+	}
+	frame, err := readFrame(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(frame) != "next\n" {
+		t.Errorf("frame != %q; got %q", "next\n", frame)
+	}
+}
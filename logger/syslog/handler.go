@@ -1,5 +1,7 @@
 package syslog
 
+import "sync/atomic"
+
 // Handler handles syslog messages
 type Handler interface {
 	// Handle should return Message (maybe modified) for future processing by
@@ -10,12 +12,31 @@ type Handler interface {
 
 // BaseHandler is designed to simplify the creation of real handlers. It
 // implements Handler interface using nonblocking queuing of messages and
-// simple message filtering.
+// simple message filtering. Messages arriving while the queue is full are
+// dropped and counted, unless Block or SpillTo is used.
 type BaseHandler struct {
-	queue  chan SyslogMessage
-	end    chan struct{}
-	filter func(SyslogMessage) bool
-	ft     bool
+	// updated atomically, so kept first for 64-bit alignment
+	dropped uint64
+	spilled uint64
+	queue   chan SyslogMessage
+	end     chan struct{}
+	filter  func(SyslogMessage) bool
+	ft      bool
+	block   bool
+	spill   *spill
+}
+
+// QueueStats describes the queue of a BaseHandler.
+type QueueStats struct {
+	// Depth is the number of messages waiting in the queue.
+	Depth    int
+	Capacity int
+	// Dropped is the number of messages dropped because the queue was full.
+	Dropped uint64
+	// Spilled is the number of messages written to the spill file, and SpillBytes the size
+	// of those still waiting in it.
+	Spilled    uint64
+	SpillBytes int64
 }
 
 // NewBaseHandler creates BaseHandler using a specified filter. If filter is nil
@@ -31,23 +52,60 @@ func NewBaseHandler(qlen int, filter func(SyslogMessage) bool, ft bool) *BaseHan
 	}
 }
 
+// Block makes Handle wait for room in the queue instead of dropping messages.
+// This slows down the senders of stream connections, while datagrams pile up
+// in the socket buffer. It must be called before the first message is handled.
+func (h *BaseHandler) Block() {
+	h.block = true
+}
+
+// SpillTo makes Handle write messages to a file at path while the queue is
+// full, and feed them back to the queue in order as it empties. Messages are
+// dropped once the file holds maxSize bytes, or never if maxSize is 0.
+// Messages spilled but not handled before a crash are replayed. It must be
+// called before the first message is handled.
+func (h *BaseHandler) SpillTo(path string, maxSize int64) error {
+	sp, err := newSpill(path, maxSize, h.queue)
+	if err != nil {
+		return err
+	}
+	h.spill = sp
+	return nil
+}
+
 // Handle inserts m in an internal queue. It immediately returns even if
-// queue is full. If m == nil it closes queue and waits for End method call
-// before return.
+// queue is full, unless Block was called. If m == nil it closes queue and
+// waits for End method call before return.
 func (h *BaseHandler) Handle(m SyslogMessage) SyslogMessage {
 	if m == nil {
-		close(h.queue) // signal that there is no more messages for processing
-		<-h.end        // wait for handler shutdown
+		if h.spill != nil {
+			h.spill.close() // closes the queue once the spilled messages are in it
+		} else {
+			close(h.queue) // signal that there is no more messages for processing
+		}
+		<-h.end // wait for handler shutdown
 		return nil
 	}
 	if h.filter != nil && !h.filter(m) {
 		// m doesn't match the filter
 		return m
 	}
-	// Try queue m
-	select {
-	case h.queue <- m:
+	switch {
+	case h.spill != nil:
+		if spilled, err := h.spill.put(m); err != nil {
+			atomic.AddUint64(&h.dropped, 1)
+		} else if spilled {
+			atomic.AddUint64(&h.spilled, 1)
+		}
+	case h.block:
+		h.queue <- m
 	default:
+		// Try queue m
+		select {
+		case h.queue <- m:
+		default:
+			atomic.AddUint64(&h.dropped, 1)
+		}
 	}
 	if h.ft {
 		return m
@@ -74,6 +132,20 @@ func (h *BaseHandler) Queue() <-chan SyslogMessage {
 	return h.queue
 }
 
+// Stats returns the state of the queue and how many messages overflowed it.
+func (h *BaseHandler) Stats() QueueStats {
+	stats := QueueStats{
+		Depth:    len(h.queue),
+		Capacity: cap(h.queue),
+		Dropped:  atomic.LoadUint64(&h.dropped),
+		Spilled:  atomic.LoadUint64(&h.spilled),
+	}
+	if h.spill != nil {
+		stats.SpillBytes = h.spill.bytes()
+	}
+	return stats
+}
+
 // End signals the server that the handler properly shutdown. You need to call End
 // only if Get has returned nil before.
 func (h *BaseHandler) End() {
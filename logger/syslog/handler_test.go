@@ -0,0 +1,151 @@
+package syslog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// drainHandler reads the messages in h until it is shut down, then returns them.
+func drainHandler(h *BaseHandler) chan []string {
+	done := make(chan []string, 1)
+	go func() {
+		var got []string
+		for m := h.Get(); m != nil; m = h.Get() {
+			got = append(got, m.String())
+		}
+		h.End()
+		done <- got
+	}()
+	return done
+}
+
+func numbered(n int) []string {
+	var messages []string
+	for i := 0; i < n; i++ {
+		messages = append(messages, "<13>message "+strconv.Itoa(i))
+	}
+	return messages
+}
+
+func TestBaseHandlerDrops(t *testing.T) {
+	h := NewBaseHandler(2, nil, false)
+	for _, m := range numbered(5) {
+		h.Handle(Parse([]byte(m)))
+	}
+	stats := h.Stats()
+	if stats.Depth != 2 || stats.Capacity != 2 || stats.Dropped != 3 {
+		t.Errorf("expected 2 queued and 3 dropped; got %+v", stats)
+	}
+	done := drainHandler(h)
+	h.Handle(nil)
+	if got := <-done; !reflect.DeepEqual(got, numbered(2)) {
+		t.Errorf("unexpected messages; got %v", got)
+	}
+}
+
+func TestBaseHandlerBlocks(t *testing.T) {
+	h := NewBaseHandler(1, nil, false)
+	h.Block()
+	handled := make(chan bool)
+	go func() {
+		for _, m := range numbered(3) {
+			h.Handle(Parse([]byte(m)))
+		}
+		handled <- true
+	}()
+	select {
+	case <-handled:
+		t.Fatal("expected Handle to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	done := drainHandler(h)
+	<-handled
+	h.Handle(nil)
+	if got := <-done; !reflect.DeepEqual(got, numbered(3)) {
+		t.Errorf("unexpected messages; got %v", got)
+	}
+	if stats := h.Stats(); stats.Dropped != 0 {
+		t.Errorf("expected nothing dropped; got %+v", stats)
+	}
+}
+
+func TestBaseHandlerSpills(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	h := NewBaseHandler(2, nil, false)
+	if err = h.SpillTo(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range numbered(10) {
+		h.Handle(Parse([]byte(m)))
+	}
+	if stats := h.Stats(); stats.Dropped != 0 || stats.Spilled < 7 || stats.SpillBytes == 0 {
+		t.Errorf("expected the overflow to be spilled; got %+v", stats)
+	}
+	done := drainHandler(h)
+	h.Handle(nil)
+	if got := <-done; !reflect.DeepEqual(got, numbered(10)) {
+		t.Errorf("expected every message in order; got %v", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the spill file to be removed once empty")
+	}
+}
+
+func TestBaseHandlerSpillLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := NewBaseHandler(1, nil, false)
+	// room for two spilled messages
+	if err = h.SpillTo(filepath.Join(dir, "queue"), int64(2*(4+len("<13>message 0")))); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range numbered(6) {
+		h.Handle(Parse([]byte(m)))
+	}
+	done := drainHandler(h)
+	h.Handle(nil)
+	got := <-done
+	if stats := h.Stats(); int(stats.Dropped) != 6-len(got) || len(got) < 3 {
+		t.Errorf("expected the messages beyond the limit to be dropped; got %v and %+v", got, stats)
+	}
+}
+
+func TestBaseHandlerReplaysSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+	// a spill file left behind, the last message cut short
+	contents := []byte("\x00\x00\x00\x0f<13>left behind\x00\x00\x00\x20<13>cut")
+	if err = ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewBaseHandler(2, nil, false)
+	if err = h.SpillTo(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	done := drainHandler(h)
+	h.Handle(Parse([]byte("<13>new")))
+	h.Handle(nil)
+	if got := <-done; !reflect.DeepEqual(got, []string{"<13>left behind", "<13>new"}) {
+		t.Errorf("expected the spilled message to be replayed first; got %v", got)
+	}
+}
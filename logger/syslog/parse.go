@@ -0,0 +1,355 @@
+package syslog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	dtime "github.com/deis/deis/pkg/time"
+)
+
+// Format is the header format a message was parsed with.
+type Format byte
+
+const (
+	// FormatUnknown - the message had no recognizable header, only Msg is set
+	FormatUnknown Format = iota
+	// FormatRFC3164 - BSD syslog, including the header-less lines logspout sends
+	FormatRFC3164
+	// FormatRFC5424 - the syslog protocol
+	FormatRFC5424
+)
+
+var formatToStr = [...]string{
+	"unknown",
+	"rfc3164",
+	"rfc5424",
+}
+
+// String returns a string representation of the Format. This satisfies the
+// fmt.Stringer interface.
+func (f Format) String() string {
+	if f > FormatRFC5424 {
+		return "unknown"
+	}
+	return formatToStr[f]
+}
+
+const (
+	nilValue = "-"
+	utf8BOM  = "\xef\xbb\xbf"
+)
+
+// SDParam is a name/value pair of an RFC 5424 structured data element.
+type SDParam struct {
+	Name  string
+	Value string
+}
+
+// SDElement is an RFC 5424 structured data element. Params keep their order and
+// names may repeat.
+type SDElement struct {
+	ID     string
+	Params []SDParam
+}
+
+// Param returns the value of the first param called name.
+func (e SDElement) Param(name string) (string, bool) {
+	for _, p := range e.Params {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ParsedMessage is a syslog message with its header parsed into fields. Fields
+// missing from the packet, or given as the RFC 5424 NILVALUE, are left empty.
+// Packets without a PRI get the RFC 3164 default of user.notice.
+type ParsedMessage struct {
+	Raw            string
+	Format         Format
+	Facility       Facility
+	Severity       Severity
+	Version        int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData []SDElement
+	Msg            string
+}
+
+// String returns the packet as it was received, so handlers that work on the
+// text of a message see the same thing as with Message.
+func (m *ParsedMessage) String() string {
+	return strings.TrimSuffix(m.Raw, "\n")
+}
+
+// Parse parses a syslog packet. RFC 5424 is tried first, then RFC 3164. A
+// packet matching neither is returned with FormatUnknown and its text in Msg.
+func Parse(packet []byte) *ParsedMessage {
+	raw := string(packet)
+	text := strings.TrimRight(raw, "\r\n\x00")
+	m := &ParsedMessage{Raw: raw, Facility: User, Severity: Notice}
+
+	rest, hasPri := m.parsePriority(text)
+	if hasPri && m.parseRFC5424(rest) == nil {
+		m.Format = FormatRFC5424
+		return m
+	}
+	// RFC 5424 parsing may have filled in some fields before failing
+	*m = ParsedMessage{Raw: m.Raw, Facility: m.Facility, Severity: m.Severity}
+
+	if m.parseRFC3164(rest) || hasPri {
+		m.Format = FormatRFC3164
+		return m
+	}
+	m.Msg = text
+	return m
+}
+
+// parsePriority parses a leading <PRI> and returns the rest of the packet.
+func (m *ParsedMessage) parsePriority(s string) (string, bool) {
+	if len(s) < 3 || s[0] != '<' {
+		return s, false
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return s, false
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 || (end > 2 && s[1] == '0') {
+		return s, false
+	}
+	m.Facility = Facility(pri / 8)
+	m.Severity = Severity(pri % 8)
+	return s[end+1:], true
+}
+
+var errBadHeader = errors.New("invalid RFC 5424 header")
+
+// parseRFC5424 parses everything after the PRI of an RFC 5424 message:
+// VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func (m *ParsedMessage) parseRFC5424(s string) error {
+	fields := strings.SplitN(s, " ", 7)
+	if len(fields) < 7 {
+		return errBadHeader
+	}
+
+	version, err := strconv.Atoi(fields[0])
+	if err != nil || version < 1 || version > 99 || fields[0][0] == '0' {
+		return errBadHeader
+	}
+	m.Version = version
+
+	if fields[1] != nilValue {
+		if m.Timestamp, err = time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+			return errBadHeader
+		}
+	}
+
+	limits := []int{255, 48, 128, 32}
+	targets := []*string{&m.Hostname, &m.AppName, &m.ProcID, &m.MsgID}
+	for i, field := range fields[2:6] {
+		if len(field) == 0 || len(field) > limits[i] || !isPrintUSASCII(field) {
+			return errBadHeader
+		}
+		if field != nilValue {
+			*targets[i] = field
+		}
+	}
+
+	sd, msg, err := parseStructuredData(fields[6])
+	if err != nil {
+		return err
+	}
+	m.StructuredData = sd
+	m.Msg = strings.TrimPrefix(msg, utf8BOM)
+	return nil
+}
+
+// parseStructuredData parses the STRUCTURED-DATA of an RFC 5424 message and
+// returns it with the MSG that follows.
+func parseStructuredData(s string) ([]SDElement, string, error) {
+	if s == nilValue {
+		return nil, "", nil
+	}
+	if strings.HasPrefix(s, nilValue+" ") {
+		return nil, s[2:], nil
+	}
+
+	var elements []SDElement
+	for len(s) > 0 && s[0] == '[' {
+		e, rest, err := parseSDElement(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		elements = append(elements, e)
+		s = rest
+	}
+	if elements == nil {
+		return nil, "", errBadHeader
+	}
+	if s == "" {
+		return elements, "", nil
+	}
+	if s[0] != ' ' {
+		return nil, "", errBadHeader
+	}
+	return elements, s[1:], nil
+}
+
+// parseSDElement parses an element after its opening bracket, up to and
+// including the closing one.
+func parseSDElement(s string) (SDElement, string, error) {
+	e := SDElement{}
+	end := strings.IndexAny(s, " ]")
+	if end < 1 || !isSDName(s[:end]) {
+		return e, "", errBadHeader
+	}
+	e.ID = s[:end]
+	s = s[end:]
+
+	for {
+		if s == "" {
+			return e, "", errBadHeader
+		}
+		if s[0] == ']' {
+			return e, s[1:], nil
+		}
+		// SP PARAM-NAME "=" %d34 PARAM-VALUE %d34
+		eq := strings.IndexByte(s, '=')
+		if s[0] != ' ' || eq < 2 || !isSDName(s[1:eq]) || len(s) < eq+2 || s[eq+1] != '"' {
+			return e, "", errBadHeader
+		}
+		name := s[1:eq]
+		value, rest, err := parseSDValue(s[eq+2:])
+		if err != nil {
+			return e, "", err
+		}
+		e.Params = append(e.Params, SDParam{Name: name, Value: value})
+		s = rest
+	}
+}
+
+// parseSDValue unescapes a param value up to its closing quote.
+func parseSDValue(s string) (string, string, error) {
+	var value []byte
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			// only ", \ and ] are escaped, any other backslash is kept
+			if i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) != -1 {
+				i++
+			}
+			value = append(value, s[i])
+		case '"':
+			return string(value), s[i+1:], nil
+		default:
+			value = append(value, s[i])
+		}
+	}
+	return "", "", errBadHeader
+}
+
+func isPrintUSASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+func isSDName(s string) bool {
+	if len(s) > 32 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '=' || c == ' ' || c == ']' || c == '"' || c < 33 || c > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// rfc3164Stamps are the single token timestamps accepted in place of the RFC
+// 3164 one, such as the one logspout uses.
+var rfc3164Stamps = []string{time.RFC3339Nano, dtime.DeisDatetimeFormat}
+
+// parseRFC3164 parses everything after the PRI of a BSD syslog message:
+// TIMESTAMP [HOSTNAME] TAG[PID]: MSG
+// It reports whether a timestamp was found.
+func (m *ParsedMessage) parseRFC3164(s string) bool {
+	rest, ok := m.parseRFC3164Timestamp(s)
+	if !ok {
+		m.Msg = s
+		return false
+	}
+
+	fields := strings.SplitN(rest, " ", 2)
+	if !isTag(fields[0]) && len(fields) == 2 && isPrintUSASCII(fields[0]) {
+		m.Hostname = fields[0]
+		rest = fields[1]
+		fields = strings.SplitN(rest, " ", 2)
+	}
+	if !isTag(fields[0]) {
+		m.Msg = rest
+		return true
+	}
+
+	tag := strings.TrimSuffix(fields[0], ":")
+	if open := strings.IndexByte(tag, '['); open != -1 {
+		m.ProcID = tag[open+1 : len(tag)-1]
+		tag = tag[:open]
+	}
+	m.AppName = tag
+	if len(fields) == 2 {
+		m.Msg = fields[1]
+	}
+	return true
+}
+
+// parseRFC3164Timestamp parses either an RFC 3164 "Mmm dd hh:mm:ss" timestamp or
+// one of rfc3164Stamps. RFC 3164 timestamps have no year, so the current one is
+// assumed.
+func (m *ParsedMessage) parseRFC3164Timestamp(s string) (string, bool) {
+	if len(s) >= len(time.Stamp)+1 && s[len(time.Stamp)] == ' ' {
+		if t, err := time.Parse(time.Stamp, s[:len(time.Stamp)]); err == nil {
+			now := time.Now()
+			m.Timestamp = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(),
+				t.Second(), 0, time.Local)
+			return s[len(time.Stamp)+1:], true
+		}
+	}
+
+	fields := strings.SplitN(s, " ", 2)
+	if len(fields) < 2 {
+		return s, false
+	}
+	for _, layout := range rfc3164Stamps {
+		if t, err := time.Parse(layout, fields[0]); err == nil {
+			m.Timestamp = t
+			return fields[1], true
+		}
+	}
+	return s, false
+}
+
+// isTag reports whether s looks like "tag:" or "tag[pid]:".
+func isTag(s string) bool {
+	if !strings.HasSuffix(s, ":") || len(s) < 2 {
+		return false
+	}
+	s = s[:len(s)-1]
+	if open := strings.IndexByte(s, '['); open != -1 {
+		if open == 0 || !strings.HasSuffix(s, "]") || open == len(s)-2 {
+			return false
+		}
+		s = s[:open]
+	}
+	return !strings.ContainsAny(s, "[]: ")
+}
@@ -0,0 +1,155 @@
+package syslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	packet := `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+		`[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"]` +
+		`[examplePriority@32473 class="high"] An application event log entry...` + "\n"
+	m := Parse([]byte(packet))
+
+	if m.Format != FormatRFC5424 {
+		t.Fatalf("format != rfc5424; got %s", m.Format)
+	}
+	if m.Facility != Local4 || m.Severity != Notice {
+		t.Errorf("priority != local4.notice; got %s.%s", m.Facility, m.Severity)
+	}
+	if m.Version != 1 {
+		t.Errorf("version != 1; got %d", m.Version)
+	}
+	expected := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)
+	if !m.Timestamp.Equal(expected) {
+		t.Errorf("timestamp != %v; got %v", expected, m.Timestamp)
+	}
+	if m.Hostname != "mymachine.example.com" || m.AppName != "evntslog" || m.ProcID != "" ||
+		m.MsgID != "ID47" {
+		t.Errorf("unexpected header fields; got %q %q %q %q", m.Hostname, m.AppName, m.ProcID,
+			m.MsgID)
+	}
+	sd := []SDElement{
+		{ID: "exampleSDID@32473", Params: []SDParam{
+			{"iut", "3"}, {"eventSource", "Application"}, {"eventID", "1011"}}},
+		{ID: "examplePriority@32473", Params: []SDParam{{"class", "high"}}},
+	}
+	if !reflect.DeepEqual(m.StructuredData, sd) {
+		t.Errorf("structured data != %v; got %v", sd, m.StructuredData)
+	}
+	if m.Msg != "An application event log entry..." {
+		t.Errorf("unexpected msg; got %q", m.Msg)
+	}
+	if m.String() != packet[:len(packet)-1] {
+		t.Errorf("String() should return the packet; got %q", m.String())
+	}
+}
+
+func TestParseRFC5424NilValues(t *testing.T) {
+	m := Parse([]byte("<34>1 - - - - - -"))
+
+	if m.Format != FormatRFC5424 {
+		t.Fatalf("format != rfc5424; got %s", m.Format)
+	}
+	if !m.Timestamp.IsZero() || m.Hostname != "" || m.AppName != "" || m.ProcID != "" ||
+		m.MsgID != "" || m.StructuredData != nil || m.Msg != "" {
+		t.Errorf("nil values should leave fields empty; got %+v", m)
+	}
+}
+
+func TestParseStructuredDataEscapes(t *testing.T) {
+	m := Parse([]byte(`<14>1 - host app 42 - [meta@1 path="C:\\deis" quote="\"hi\"" ` +
+		`bracket="[x\]" other="\n"] msg`))
+
+	if m.Format != FormatRFC5424 {
+		t.Fatalf("format != rfc5424; got %s", m.Format)
+	}
+	values := map[string]string{
+		"path": `C:\deis`, "quote": `"hi"`, "bracket": "[x]", "other": `\n`,
+	}
+	for name, expected := range values {
+		if value, ok := m.StructuredData[0].Param(name); !ok || value != expected {
+			t.Errorf("param %s != %q; got %q", name, expected, value)
+		}
+	}
+	if m.ProcID != "42" || m.Msg != "msg" {
+		t.Errorf("unexpected procid or msg; got %q %q", m.ProcID, m.Msg)
+	}
+}
+
+func TestParseRFC5424Invalid(t *testing.T) {
+	packets := []string{
+		"<14>1 2003-10-11T22:14:15.003Z host app - - [unterminated msg",
+		"<14>1 2003-10-11T22:14:15.003Z host app - - [id bad] msg",
+		"<14>1 not-a-time host app - - - msg",
+		"<14>0 - host app - - - msg",
+		"<14>1 - host app -",
+	}
+	for _, packet := range packets {
+		if m := Parse([]byte(packet)); m.Format != FormatRFC3164 {
+			t.Errorf("%q should fall back to rfc3164; got %s", packet, m.Format)
+		}
+	}
+}
+
+func TestParseRFC3164(t *testing.T) {
+	m := Parse([]byte("<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed on /dev/pts/8"))
+
+	if m.Format != FormatRFC3164 {
+		t.Fatalf("format != rfc3164; got %s", m.Format)
+	}
+	if m.Facility != Auth || m.Severity != Crit {
+		t.Errorf("priority != auth.crit; got %s.%s", m.Facility, m.Severity)
+	}
+	if m.Timestamp.Month() != time.October || m.Timestamp.Day() != 11 ||
+		m.Timestamp.Year() != time.Now().Year() {
+		t.Errorf("unexpected timestamp; got %v", m.Timestamp)
+	}
+	if m.Hostname != "mymachine" || m.AppName != "su" || m.ProcID != "230" {
+		t.Errorf("unexpected header fields; got %q %q %q", m.Hostname, m.AppName, m.ProcID)
+	}
+	if m.Msg != "'su root' failed on /dev/pts/8" {
+		t.Errorf("unexpected msg; got %q", m.Msg)
+	}
+}
+
+func TestParseLogspout(t *testing.T) {
+	m := Parse([]byte("2015-06-15T18:40:09UTC myapp[web.1]: listening on 5000\n"))
+
+	if m.Format != FormatRFC3164 {
+		t.Fatalf("format != rfc3164; got %s", m.Format)
+	}
+	if m.Facility != User || m.Severity != Notice {
+		t.Errorf("priority should default to user.notice; got %s.%s", m.Facility, m.Severity)
+	}
+	if m.Timestamp.IsZero() || m.Hostname != "" || m.AppName != "myapp" || m.ProcID != "web.1" {
+		t.Errorf("unexpected header fields; got %v %q %q %q", m.Timestamp, m.Hostname, m.AppName,
+			m.ProcID)
+	}
+	if m.Msg != "listening on 5000" {
+		t.Errorf("unexpected msg; got %q", m.Msg)
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	m := Parse([]byte("localhost test message\n"))
+
+	if m.Format != FormatUnknown {
+		t.Errorf("format != unknown; got %s", m.Format)
+	}
+	if m.Msg != "localhost test message" || m.String() != "localhost test message" {
+		t.Errorf("unexpected msg; got %q", m.Msg)
+	}
+}
+
+func TestParsePriorityOnly(t *testing.T) {
+	m := Parse([]byte("<11>something went wrong"))
+
+	if m.Format != FormatRFC3164 || m.Facility != User || m.Severity != Err {
+		t.Errorf("unexpected format or priority; got %s %s.%s", m.Format, m.Facility, m.Severity)
+	}
+	if m.Msg != "something went wrong" {
+		t.Errorf("unexpected msg; got %q", m.Msg)
+	}
+}
@@ -1,26 +1,39 @@
-// Package syslog implements a syslog server library. It is based on RFC 3164,
-// as such it does not properly parse packets with an RFC 5424 header format.
+// Package syslog implements a syslog server library. Packets are parsed as RFC
+// 5424 messages, falling back to RFC 3164, and passed to handlers as
+// *ParsedMessage.
 package syslog
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
 	"log"
 	"net"
 	"os"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 )
 
 // Server is the wrapper for a syslog server.
 type Server struct {
-	conns    []net.PacketConn
-	handlers []Handler
-	shutdown bool
-	l        FatalLogger
+	conns     []net.PacketConn
+	listeners []net.Listener
+	streams   map[net.Conn]struct{}
+	handlers  []Handler
+	shutdown  bool
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+	l         FatalLogger
 }
 
 // NewServer creates an idle server.
 func NewServer() *Server {
-	return &Server{l: log.New(os.Stderr, "", log.LstdFlags)}
+	return &Server{
+		streams: make(map[net.Conn]struct{}),
+		l:       log.New(os.Stderr, "", log.LstdFlags),
+	}
 }
 
 // SetLogger sets logger for server errors. A running server is rather quiet and
@@ -61,24 +74,74 @@ func (s *Server) Listen(addr string) error {
 		}
 	}
 	s.conns = append(s.conns, c)
+	s.wg.Add(1)
 	go s.receiver(c)
 	return nil
 }
 
-// Shutdown stops server.
+// ListenTCP starts gorutine that accepts syslog connections on the specified
+// host:port. Messages are framed as in RFC 6587, either by octet counting or by
+// newlines.
+func (s *Server) ListenTCP(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listenStream(l)
+	return nil
+}
+
+// ListenTLS is like ListenTCP, for syslog over TLS as in RFC 5425. config must
+// hold at least one certificate.
+func (s *Server) ListenTLS(addr string, config *tls.Config) error {
+	l, err := tls.Listen("tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	s.listenStream(l)
+	return nil
+}
+
+func (s *Server) listenStream(l net.Listener) {
+	s.listeners = append(s.listeners, l)
+	s.wg.Add(1)
+	go s.acceptor(l)
+}
+
+// Shutdown stops server. It waits for all receivers to finish before handlers
+// are told to shutdown.
 func (s *Server) Shutdown() {
+	s.mu.Lock()
 	s.shutdown = true
+	for c := range s.streams {
+		c.Close()
+	}
+	s.mu.Unlock()
 	for _, c := range s.conns {
 		err := c.Close()
 		if err != nil {
 			s.l.Fatalln(err)
 		}
 	}
+	for _, l := range s.listeners {
+		err := l.Close()
+		if err != nil {
+			s.l.Fatalln(err)
+		}
+	}
+	s.wg.Wait()
 	s.passToHandlers(nil)
 	s.conns = nil
+	s.listeners = nil
 	s.handlers = nil
 }
 
+func (s *Server) isShutdown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shutdown
+}
+
 func isNotAlnum(r rune) bool {
 	return !(unicode.IsLetter(r) || unicode.IsNumber(r))
 }
@@ -97,17 +160,70 @@ func (s *Server) passToHandlers(m SyslogMessage) {
 }
 
 func (s *Server) receiver(c net.PacketConn) {
+	defer s.wg.Done()
 	// make packet buffer the same size as logspout
-	buf := make([]byte, 1048576)
+	buf := make([]byte, maxMessageSize)
 	for {
 		n, _, err := c.ReadFrom(buf)
 		if err != nil {
-			if !s.shutdown {
+			if !s.isShutdown() {
 				s.l.Fatalln("Read error:", err)
 			}
 			return
 		}
 		// pass along the incoming syslog message
-		s.passToHandlers(&Message{string(buf[:n])})
+		s.passToHandlers(Parse(buf[:n]))
+	}
+}
+
+func (s *Server) acceptor(l net.Listener) {
+	defer s.wg.Done()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if s.isShutdown() {
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			s.l.Fatalln("Accept error:", err)
+			return
+		}
+		s.mu.Lock()
+		if s.shutdown {
+			s.mu.Unlock()
+			c.Close()
+			return
+		}
+		s.streams[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.streamReceiver(c)
+	}
+}
+
+// streamReceiver passes the messages of one connection to handlers until the
+// client hangs up or sends a frame that cannot be read.
+func (s *Server) streamReceiver(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+	r := bufio.NewReaderSize(c, 65536)
+	for {
+		frame, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		frame = bytes.TrimRight(frame, "\r\n")
+		if len(frame) == 0 {
+			continue
+		}
+		s.passToHandlers(Parse(frame))
 	}
 }
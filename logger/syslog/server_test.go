@@ -0,0 +1,101 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type chanHandler chan SyslogMessage
+
+func (h chanHandler) Handle(m SyslogMessage) SyslogMessage {
+	if m != nil {
+		h <- m
+	}
+	return nil
+}
+
+func expectMessages(t *testing.T, h chanHandler, expected ...string) {
+	for _, e := range expected {
+		select {
+		case m := <-h:
+			if m.String() != e {
+				t.Errorf("message != %q; got %q", e, m.String())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", e)
+		}
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	s := NewServer()
+	h := make(chanHandler, 10)
+	s.AddHandler(h)
+	if err := s.ListenTCP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+
+	c, err := net.Dial("tcp", s.listeners[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	msg := "<14>1 - host myapp web.1 - - hello"
+	fmt.Fprintf(c, "%d %s", len(msg), msg)
+	fmt.Fprint(c, "2015-06-15T18:40:09UTC myapp[web.1]: world\r\n\n")
+
+	expectMessages(t, h, msg, "2015-06-15T18:40:09UTC myapp[web.1]: world")
+}
+
+func TestListenTLS(t *testing.T) {
+	// borrow the test certificate httptest serves with
+	ts := httptest.NewTLSServer(nil)
+	config := &tls.Config{Certificates: ts.TLS.Certificates}
+	ts.Close()
+
+	s := NewServer()
+	h := make(chanHandler, 10)
+	s.AddHandler(h)
+	if err := s.ListenTLS("127.0.0.1:0", config); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown()
+
+	c, err := tls.Dial("tcp", s.listeners[0].Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	fmt.Fprint(c, "<14>Oct 11 22:14:15 host myapp[web.1]: secure\n")
+
+	expectMessages(t, h, "<14>Oct 11 22:14:15 host myapp[web.1]: secure")
+}
+
+func TestShutdownClosesStreams(t *testing.T) {
+	s := NewServer()
+	s.AddHandler(make(chanHandler, 10))
+	if err := s.ListenTCP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := net.Dial("tcp", s.listeners[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not return with a client connected")
+	}
+}
@@ -0,0 +1,164 @@
+package syslog
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"os"
+	"sync"
+)
+
+var errSpillFull = errors.New("spill file is full")
+
+// spill holds messages in a file while a handler queue is full, each as its length then its
+// raw bytes, and feeds them back to the queue oldest first.
+type spill struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	f       *os.File
+	readOff int64
+	size    int64
+	maxSize int64
+	// inFlight is set while a message read back is being queued, so newer messages wait
+	// behind it
+	inFlight bool
+	closing  bool
+	queue    chan SyslogMessage
+}
+
+func newSpill(path string, maxSize int64, queue chan SyslogMessage) (*spill, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sp := &spill{f: f, size: info.Size(), maxSize: maxSize, queue: queue}
+	// drop a message cut short, as by a crash while it was written, so new ones follow the
+	// last whole message
+	sp.size = sp.complete()
+	if err = f.Truncate(sp.size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	sp.cond = sync.NewCond(&sp.mu)
+	go sp.feed()
+	return sp, nil
+}
+
+// put passes m to the queue if it has room and nothing is waiting in the file, otherwise it
+// appends m to the file. It reports whether m was spilled.
+func (sp *spill) put(m SyslogMessage) (bool, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.readOff == sp.size && !sp.inFlight {
+		select {
+		case sp.queue <- m:
+			return false, nil
+		default:
+		}
+	}
+	raw := m.String()
+	if pm, ok := m.(*ParsedMessage); ok {
+		raw = pm.Raw
+	}
+	record := make([]byte, 4+len(raw))
+	binary.BigEndian.PutUint32(record, uint32(len(raw)))
+	copy(record[4:], raw)
+	if sp.maxSize > 0 && sp.size+int64(len(record)) > sp.maxSize {
+		return false, errSpillFull
+	}
+	if _, err := sp.f.WriteAt(record, sp.size); err != nil {
+		return false, err
+	}
+	sp.size += int64(len(record))
+	sp.cond.Signal()
+	return true, nil
+}
+
+// bytes returns the size of the messages waiting in the file.
+func (sp *spill) bytes() int64 {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.size - sp.readOff
+}
+
+// close makes feed close the queue once every spilled message is in it.
+func (sp *spill) close() {
+	sp.mu.Lock()
+	sp.closing = true
+	sp.cond.Broadcast()
+	sp.mu.Unlock()
+}
+
+func (sp *spill) feed() {
+	defer close(sp.queue)
+	for {
+		sp.mu.Lock()
+		for sp.readOff == sp.size && !sp.closing {
+			sp.cond.Wait()
+		}
+		if sp.readOff == sp.size {
+			sp.f.Close()
+			os.Remove(sp.f.Name())
+			sp.mu.Unlock()
+			return
+		}
+		raw, err := sp.read()
+		sp.inFlight = err == nil
+		sp.mu.Unlock()
+		if err != nil {
+			log.Printf("discarding unreadable spilled messages: %v\n", err)
+			continue
+		}
+		sp.queue <- Parse(raw)
+		sp.mu.Lock()
+		sp.inFlight = false
+		sp.mu.Unlock()
+	}
+}
+
+// complete returns the size of the whole messages at the start of the file.
+func (sp *spill) complete() int64 {
+	var off int64
+	var header [4]byte
+	for off+4 <= sp.size {
+		if _, err := sp.f.ReadAt(header[:], off); err != nil {
+			break
+		}
+		n := int64(binary.BigEndian.Uint32(header[:]))
+		if off+4+n > sp.size {
+			break
+		}
+		off += 4 + n
+	}
+	return off
+}
+
+// read returns the oldest message in the file, emptying the file once every message has been
+// read. If it cannot be read, it is discarded along with anything after it. It must be called
+// with sp.mu held.
+func (sp *spill) read() ([]byte, error) {
+	var header [4]byte
+	_, err := sp.f.ReadAt(header[:], sp.readOff)
+	var raw []byte
+	if err == nil {
+		raw = make([]byte, binary.BigEndian.Uint32(header[:]))
+		_, err = sp.f.ReadAt(raw, sp.readOff+4)
+	}
+	if err == nil {
+		sp.readOff += 4 + int64(len(raw))
+	} else {
+		sp.readOff = sp.size
+	}
+	if sp.readOff >= sp.size {
+		sp.readOff, sp.size = 0, 0
+		if truncErr := sp.f.Truncate(0); truncErr != nil && err == nil {
+			err = truncErr
+		}
+	}
+	return raw, err
+}
@@ -1,26 +1,86 @@
 package syslogd
 
 import (
+	"crypto/tls"
 	"fmt"
-	"io"
 	"log"
-	"os"
-	"path"
 	"regexp"
+	"sync"
+	"time"
 
 	"github.com/deis/deis/logger/syslog"
 
 	"github.com/deis/deis/logger/drain"
+	"github.com/deis/deis/logger/logstore"
 )
 
-// LogRoot is the log path to store logs.
-var LogRoot string
+// TLSCertFile and TLSKeyFile are the PEM encoded certificate and key used by tls listeners.
+var (
+	TLSCertFile string
+	TLSKeyFile  string
+)
+
+// The queue between the syslog server and the log store and drains.
+var (
+	// QueueSize is the number of messages the queue holds in memory.
+	QueueSize = 1024
+	// QueueOverflow is what happens to messages arriving while the queue is full: they are
+	// dropped, wait for room (block) or are written to SpillPath (spill).
+	QueueOverflow = "drop"
+	// SpillPath is the file messages spill to, which holds up to SpillSize bytes.
+	SpillPath string
+	SpillSize int64
+)
+
+var (
+	activeMu sync.Mutex
+	active   *handler
+)
+
+// Listener is an address the syslog server receives messages on with one of the udp, tcp or
+// tls protocols.
+type Listener struct {
+	Protocol string
+	Addr     string
+}
+
+func (l Listener) listen(s *syslog.Server) error {
+	switch l.Protocol {
+	case "udp":
+		return s.Listen(l.Addr)
+	case "tcp":
+		return s.ListenTCP(l.Addr)
+	case "tls":
+		cert, err := tls.LoadX509KeyPair(TLSCertFile, TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		return s.ListenTLS(l.Addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+	}
+	return fmt.Errorf("unknown protocol %s", l.Protocol)
+}
 
 type handler struct {
 	// To simplify implementation of our handler we embed helper
 	// syslog.BaseHandler struct.
 	*syslog.BaseHandler
-	drainURI string
+	drains *drain.Drains
+	store  *logstore.Store
+	mu     sync.Mutex
+	stats  Stats
+}
+
+// Stats counts what the handler did with the messages it received.
+type Stats struct {
+	// Received counts the messages of each app.
+	Received map[string]uint64
+	// UnknownApp counts the messages no app name could be found in.
+	UnknownApp uint64
+	// BytesWritten counts the bytes written to app logs, and WriteErrors the failed writes.
+	BytesWritten uint64
+	WriteErrors  uint64
+	Queue        syslog.QueueStats
+	Drains       []drain.Stats
 }
 
 // Simple fiter for named/bind messages which can be used with BaseHandler
@@ -28,58 +88,74 @@ func filter(m syslog.SyslogMessage) bool {
 	return true
 }
 
-func newHandler() *handler {
+func newHandler(store *logstore.Store) (*handler, error) {
 	h := handler{
-		BaseHandler: syslog.NewBaseHandler(5, filter, false),
+		BaseHandler: syslog.NewBaseHandler(QueueSize, filter, false),
+		drains:      drain.NewDrains(),
+		store:       store,
+		stats:       Stats{Received: make(map[string]uint64)},
+	}
+	switch QueueOverflow {
+	case "drop":
+	case "block":
+		h.Block()
+	case "spill":
+		if err := h.SpillTo(SpillPath, SpillSize); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown queue overflow %q, use drop, block or spill", QueueOverflow)
 	}
 
 	go h.mainLoop() // BaseHandler needs some goroutine that reads from its queue
-	return &h
+	return &h, nil
 }
 
-// check if a file path exists
-func fileExists(path string) (bool, error) {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true, nil
+// GetStats returns the counters of the running handler, which are empty until Listen has
+// started.
+func GetStats() Stats {
+	activeMu.Lock()
+	h := active
+	activeMu.Unlock()
+	if h == nil {
+		return Stats{}
 	}
-	if os.IsNotExist(err) {
-		return false, nil
+	h.mu.Lock()
+	stats := h.stats
+	stats.Received = make(map[string]uint64, len(h.stats.Received))
+	for app, n := range h.stats.Received {
+		stats.Received[app] = n
 	}
-	return false, err
+	h.mu.Unlock()
+	stats.Queue = h.Stats()
+	stats.Drains = h.drains.Stats()
+	return stats
 }
 
-func getLogFile(message string) (io.Writer, error) {
-	r := regexp.MustCompile(`^.* ([-a-z0-9]+)\[[a-z0-9-_\.]+\].*`)
-	match := r.FindStringSubmatch(message)
-	if match == nil {
-		return nil, fmt.Errorf("Could not find app name in message: %s", message)
-	}
-	appName := match[1]
-	filePath := path.Join(LogRoot, appName+".log")
-	// check if file exists
-	exists, err := fileExists(filePath)
-	if err != nil {
-		return nil, err
+var (
+	appNameRegex    = regexp.MustCompile(`^[-a-z0-9]+$`)
+	appMessageRegex = regexp.MustCompile(`^.* ([-a-z0-9]+)\[[a-z0-9-_\.]+\].*`)
+)
+
+// getAppName returns the app a message belongs to, preferring the app name parsed from its
+// header over matching its text.
+func getAppName(m syslog.SyslogMessage) (string, error) {
+	if parsed, ok := m.(*syslog.ParsedMessage); ok && appNameRegex.MatchString(parsed.AppName) {
+		return parsed.AppName, nil
 	}
-	// return a new file or the existing file for appending
-	var file io.Writer
-	if exists {
-		file, err = os.OpenFile(filePath, os.O_RDWR|os.O_APPEND, 0644)
-	} else {
-		file, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
+	match := appMessageRegex.FindStringSubmatch(m.String())
+	if match == nil {
+		return "", fmt.Errorf("Could not find app name in message: %s", m)
 	}
-	return file, err
+	return match[1], nil
 }
 
-func writeToDisk(m syslog.SyslogMessage) error {
-	file, err := getLogFile(m.String())
-	if err != nil {
-		return err
+// messageTime returns the time a message was logged at, or now if its header has none.
+func messageTime(m syslog.SyslogMessage) time.Time {
+	if parsed, ok := m.(*syslog.ParsedMessage); ok && !parsed.Timestamp.IsZero() {
+		return parsed.Timestamp
 	}
-	bytes := []byte(m.String() + "\n")
-	file.Write(bytes)
-	return nil
+	return time.Now()
 }
 
 // mainLoop reads from BaseHandler queue using h.Get and logs messages to stdout
@@ -89,32 +165,55 @@ func (h *handler) mainLoop() {
 		if m == nil {
 			break
 		}
-		if h.drainURI != "" {
-			drain.SendToDrain(m.String(), h.drainURI)
+		appName, err := getAppName(m)
+		// messages from outside any app still go to the drains not scoped to one
+		h.drains.Send(appName, m.String())
+		if err != nil {
+			h.mu.Lock()
+			h.stats.UnknownApp++
+			h.mu.Unlock()
+			log.Println(err)
+			continue
+		}
+		line := []byte(m.String())
+		err = h.store.Write(appName, messageTime(m), line)
+		h.mu.Lock()
+		h.stats.Received[appName]++
+		if err == nil {
+			h.stats.BytesWritten += uint64(len(line)) + 1
+		} else {
+			h.stats.WriteErrors++
 		}
-		err := writeToDisk(m)
+		h.mu.Unlock()
 		if err != nil {
 			log.Println(err)
 		}
 	}
+	h.drains.Close()
 	h.End()
 }
 
-// Listen starts a new syslog server which runs until it receives a signal.
-func Listen(exitChan, cleanupDone chan bool, drainChan chan string, bindAddr string) {
+// Listen starts a new syslog server which runs until it receives a signal. App logs are
+// written to store.
+func Listen(exitChan, cleanupDone chan bool, drainChan chan []drain.Config, store *logstore.Store,
+	listeners []Listener) {
 	fmt.Println("Starting syslog...")
-	// If LogRoot doesn't exist, create it
-	// equivalent to Python's `if not os.path.exists(filename)`
-	if _, err := os.Stat(LogRoot); os.IsNotExist(err) {
-		if err = os.MkdirAll(LogRoot, 0777); err != nil {
-			log.Fatalf("unable to create LogRoot at %s: %v", LogRoot, err)
-		}
-	}
-	// Create a server with one handler and run one listen goroutine
+	// Create a server with one handler and run a listen goroutine per listener
 	s := syslog.NewServer()
-	h := newHandler()
+	h, err := newHandler(store)
+	if err != nil {
+		log.Fatalf("unable to create the message queue: %v", err)
+	}
+	activeMu.Lock()
+	active = h
+	activeMu.Unlock()
 	s.AddHandler(h)
-	s.Listen(bindAddr)
+	for _, l := range listeners {
+		if err := l.listen(s); err != nil {
+			log.Fatalf("unable to listen for %s on %s: %v", l.Protocol, l.Addr, err)
+		}
+		fmt.Printf("Listening for %s on %s\n", l.Protocol, l.Addr)
+	}
 	fmt.Println("Syslog server started...")
 	fmt.Println("deis-logger running")
 
@@ -126,8 +225,8 @@ func Listen(exitChan, cleanupDone chan bool, drainChan chan string, bindAddr str
 			fmt.Println("Shutting down...")
 			s.Shutdown()
 			cleanupDone <- true
-		case d := <-drainChan:
-			h.drainURI = d
+		case configs := <-drainChan:
+			h.drains.Update(configs)
 		}
 	}
 }
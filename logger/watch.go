@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/coreos/go-etcd/etcd"
+)
+
+// etcdEventIndexCleared is returned for a watch from an index etcd no longer keeps the history of.
+const etcdEventIndexCleared = 401
+
+// watchRetryInterval is how long a failed watch waits before it is tried again.
+var watchRetryInterval = 5 * time.Second
+
+// watchKey signals changed whenever key, or anything under it, changes, and once it starts
+// watching. Each watch resumes from the index after the last change seen, so no change is missed
+// between watches. While the watch fails, changed is signaled on every retry so the caller
+// polls instead. It returns once stop is closed.
+func watchKey(client *etcd.Client, key string, changed chan<- bool, stop chan bool) {
+	var index uint64
+	for {
+		if index == 0 {
+			current, err := currentIndex(client, key)
+			if err != nil {
+				log.Printf("warning: could not read %s: %v\n", key, err)
+				if !signalAndWait(changed, stop) {
+					return
+				}
+				continue
+			}
+			index = current + 1
+			notify(changed)
+		}
+
+		resp, err := client.Watch(key, index, true, nil, stop)
+		if err == etcd.ErrWatchStoppedByUser {
+			return
+		}
+		if e, ok := err.(*etcd.EtcdError); ok && e.ErrorCode == etcdEventIndexCleared {
+			// too much has changed since index to resume from it, so start over
+			index = 0
+			continue
+		}
+		if err != nil {
+			log.Printf("warning: could not watch %s, polling instead: %v\n", key, err)
+			if !signalAndWait(changed, stop) {
+				return
+			}
+			continue
+		}
+		index = resp.Node.ModifiedIndex + 1
+		notify(changed)
+	}
+}
+
+// currentIndex returns the etcd index key was read at, whether or not it exists.
+func currentIndex(client *etcd.Client, key string) (uint64, error) {
+	resp, err := client.Get(key, false, false)
+	if err != nil {
+		if e, ok := err.(*etcd.EtcdError); ok && e.ErrorCode == etcdKeyNotFound {
+			return e.Index, nil
+		}
+		return 0, err
+	}
+	return resp.EtcdIndex, nil
+}
+
+// notify sends on changed unless a signal is already waiting there.
+func notify(changed chan<- bool) {
+	select {
+	case changed <- true:
+	default:
+	}
+}
+
+// signalAndWait signals changed and waits watchRetryInterval. It returns false if stop was
+// closed meanwhile.
+func signalAndWait(changed chan<- bool, stop chan bool) bool {
+	notify(changed)
+	select {
+	case <-stop:
+		return false
+	case <-time.After(watchRetryInterval):
+		return true
+	}
+}
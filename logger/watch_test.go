@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-etcd/etcd"
+)
+
+// fakeEtcd answers reads of a missing key at index 7, and watches from a script of responses.
+type fakeEtcd struct {
+	mu      sync.Mutex
+	watches []string
+	script  []string
+	done    chan bool
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("wait") != "true" {
+		w.Header().Set("X-Etcd-Index", "7")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errorCode":100,"message":"Key not found","cause":"/deis/logs/drains","index":7}`)
+		return
+	}
+	f.mu.Lock()
+	f.watches = append(f.watches, r.URL.Query().Get("waitIndex"))
+	var resp string
+	if len(f.script) > 0 {
+		resp, f.script = f.script[0], f.script[1:]
+	}
+	f.mu.Unlock()
+	if resp == "" {
+		<-f.done
+		return
+	}
+	if resp[0] == '4' {
+		w.WriteHeader(http.StatusBadRequest)
+		resp = resp[1:]
+	}
+	fmt.Fprint(w, resp)
+}
+
+func (f *fakeEtcd) watched() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.watches...)
+}
+
+func TestWatchKey(t *testing.T) {
+	f := &fakeEtcd{
+		script: []string{
+			`{"action":"set","node":{"key":"/deis/logs/drains/a","value":"syslog://a","modifiedIndex":9}}`,
+			`4{"errorCode":401,"message":"The event in requested index is outdated and cleared","index":50}`,
+		},
+		done: make(chan bool),
+	}
+	server := httptest.NewServer(f)
+	defer server.Close()
+	defer close(f.done)
+
+	changed := make(chan bool, 10)
+	stop := make(chan bool)
+	stopped := make(chan bool)
+	go func() {
+		watchKey(etcd.NewClient([]string{server.URL}), "/deis/logs/drains", changed, stop)
+		close(stopped)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(f.watched()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for watches; got %v", f.watched())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// resumed after the change, and started over once the history was cleared
+	if expected := []string{"8", "10", "8"}; !reflect.DeepEqual(f.watched(), expected) {
+		t.Errorf("expected watches from %v; got %v", expected, f.watched())
+	}
+	// once on starting, once on the change and once on starting over
+	if len(changed) != 3 {
+		t.Errorf("expected 3 change signals; got %d", len(changed))
+	}
+
+	close(stop)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the watch to stop")
+	}
+}
@@ -6,21 +6,51 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fsouza/go-dockerclient"
 )
 
+// ListenerBuffer is the number of lines buffered for each listener. Lines for a listener whose
+// buffer is full are dropped, or the listener is evicted, so a slow listener never holds up
+// the others.
+var ListenerBuffer = 1024
+
+// SlowPolicy is what happens to a listener that falls behind.
+type SlowPolicy int
+
+const (
+	// DropLines drops the lines that do not fit in the listener's buffer.
+	DropLines SlowPolicy = iota
+	// Evict stops the listener once its buffer is full.
+	Evict
+)
+
+// logListener is a channel receiving lines from the pumps of the containers it listens to.
+type logListener struct {
+	dropped   uint64
+	ch        chan *Log
+	policy    SlowPolicy
+	evictOnce sync.Once
+	evicted   chan struct{}
+}
+
+func (l *logListener) evict() {
+	l.evictOnce.Do(func() { close(l.evicted) })
+}
+
 type AttachManager struct {
 	sync.Mutex
 	attached map[string]*LogPump
-	channels map[chan *AttachEvent]struct{}
+	channels map[chan *AttachEvent]chan struct{}
 	client   *docker.Client
 }
 
 func NewAttachManager(client *docker.Client) *AttachManager {
 	m := &AttachManager{
 		attached: make(map[string]*LogPump),
-		channels: make(map[chan *AttachEvent]struct{}),
+		channels: make(map[chan *AttachEvent]chan struct{}),
 		client:   client,
 	}
 	containers, err := client.ListContainers(docker.ListContainersOptions{})
@@ -46,6 +76,10 @@ func (m *AttachManager) attach(id string) {
 	container, err := m.client.InspectContainer(id)
 	assert(err, "attacher")
 	name := container.Name[1:]
+	var env []string
+	if container.Config != nil {
+		env = container.Config.Env
+	}
 	success := make(chan struct{})
 	failure := make(chan error)
 	outrd, outwr := io.Pipe()
@@ -76,7 +110,7 @@ func (m *AttachManager) attach(id string) {
 	_, ok := <-success
 	if ok {
 		m.Lock()
-		m.attached[id] = NewLogPump(outrd, errrd, id, name)
+		m.attached[id] = NewLogPump(outrd, errrd, id, name, getMultiline(env))
 		m.Unlock()
 		success <- struct{}{}
 		m.send(&AttachEvent{ID: id, Name: name, Type: "attach"})
@@ -86,22 +120,40 @@ func (m *AttachManager) attach(id string) {
 	debug("attach:", id, "failure:", <-failure)
 }
 
+// send passes event to every listener without holding the lock, so a listener that is
+// stopping cannot block the others.
 func (m *AttachManager) send(event *AttachEvent) {
 	m.Lock()
-	defer m.Unlock()
-	for ch, _ := range m.channels {
-		// TODO: log err after timeout and continue
-		ch <- event
+	channels := make(map[chan *AttachEvent]chan struct{}, len(m.channels))
+	for ch, done := range m.channels {
+		channels[ch] = done
+	}
+	m.Unlock()
+	for ch, done := range channels {
+		select {
+		case ch <- event:
+		case <-done:
+		}
 	}
 }
 
-func (m *AttachManager) addListener(ch chan *AttachEvent) {
+// addListener passes the attach events of every container to ch until done is closed, starting
+// with the containers already attached.
+func (m *AttachManager) addListener(ch chan *AttachEvent, done chan struct{}) {
 	m.Lock()
 	defer m.Unlock()
-	m.channels[ch] = struct{}{}
+	m.channels[ch] = done
+	var events []*AttachEvent
+	for id, pump := range m.attached {
+		events = append(events, &AttachEvent{ID: id, Name: pump.Name, Type: "attach"})
+	}
 	go func() {
-		for id, pump := range m.attached {
-			ch <- &AttachEvent{ID: id, Name: pump.Name, Type: "attach"}
+		for _, event := range events {
+			select {
+			case ch <- event:
+			case <-done:
+				return
+			}
 		}
 	}()
 }
@@ -118,13 +170,29 @@ func (m *AttachManager) Get(id string) *LogPump {
 	return m.attached[id]
 }
 
-func (m *AttachManager) Listen(source *Source, logstream chan *Log, closer <-chan bool) {
+// Listen passes the lines of the containers matching source to logstream, which should hold
+// ListenerBuffer lines, until closer fires. policy decides what happens once logstream is full.
+func (m *AttachManager) Listen(source *Source, logstream chan *Log, closer <-chan bool, policy SlowPolicy) {
 	if source == nil {
 		source = new(Source)
 	}
+	listener := &logListener{ch: logstream, policy: policy, evicted: make(chan struct{})}
+	var reported uint64
+	report := func() {
+		if dropped := atomic.LoadUint64(&listener.dropped); dropped > reported {
+			log.Printf("listener on %+v dropped %d lines, %d in all\n", *source,
+				dropped-reported, dropped)
+			reported = dropped
+		}
+	}
+	defer report()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
 	events := make(chan *AttachEvent)
-	m.addListener(events)
+	done := make(chan struct{})
+	m.addListener(events, done)
 	defer m.removeListener(events)
+	defer close(done)
 	for {
 		select {
 		case event := <-events:
@@ -133,16 +201,20 @@ func (m *AttachManager) Listen(source *Source, logstream chan *Log, closer <-cha
 				(source.Name != "" && event.Name == source.Name) ||
 				(source.Filter != "" && strings.Contains(event.Name, source.Filter))) {
 				pump := m.Get(event.ID)
-				pump.AddListener(logstream)
-				defer func() {
-					if pump != nil {
-						pump.RemoveListener(logstream)
-					}
-				}()
+				if pump == nil {
+					continue
+				}
+				pump.AddListener(listener)
+				defer pump.RemoveListener(logstream)
 			} else if source.ID != "" && event.Type == "detach" &&
 				strings.HasPrefix(event.ID, source.ID) {
 				return
 			}
+		case <-ticker.C:
+			report()
+		case <-listener.evicted:
+			log.Printf("listener on %+v evicted for falling behind\n", *source)
+			return
 		case <-closer:
 			return
 		}
@@ -153,16 +225,30 @@ type LogPump struct {
 	sync.Mutex
 	ID       string
 	Name     string
-	channels map[chan *Log]struct{}
+	channels map[chan *Log]*logListener
 }
 
-func NewLogPump(stdout, stderr io.Reader, id, name string) *LogPump {
+func NewLogPump(stdout, stderr io.Reader, id, name string, multiline *Multiline) *LogPump {
 	obj := &LogPump{
 		ID:       id,
 		Name:     name,
-		channels: make(map[chan *Log]struct{}),
+		channels: make(map[chan *Log]*logListener),
 	}
 	pump := func(typ string, source io.Reader) {
+		send := func(data string) {
+			obj.send(&Log{
+				Data: data,
+				ID:   id,
+				Name: name,
+				Type: typ,
+			})
+		}
+		var lines chan string
+		if multiline != nil {
+			lines = make(chan string)
+			defer close(lines)
+			go multiline.Join(lines, send)
+		}
 		buf := bufio.NewReader(source)
 		for {
 			data, err := buf.ReadBytes('\n')
@@ -172,12 +258,12 @@ func NewLogPump(stdout, stderr io.Reader, id, name string) *LogPump {
 				}
 				return
 			}
-			obj.send(&Log{
-				Data: strings.TrimSuffix(string(data), "\n"),
-				ID:   id,
-				Name: name,
-				Type: typ,
-			})
+			line := strings.TrimSuffix(string(data), "\n")
+			if lines != nil {
+				lines <- line
+			} else {
+				send(line)
+			}
 		}
 	}
 	go pump("stdout", stdout)
@@ -185,19 +271,27 @@ func NewLogPump(stdout, stderr io.Reader, id, name string) *LogPump {
 	return obj
 }
 
+// send passes log to every listener with room for it, never blocking.
 func (o *LogPump) send(log *Log) {
 	o.Lock()
 	defer o.Unlock()
-	for ch, _ := range o.channels {
-		// TODO: log err after timeout and continue
-		ch <- log
+	for ch, listener := range o.channels {
+		select {
+		case ch <- log:
+		default:
+			atomic.AddUint64(&listener.dropped, 1)
+			if listener.policy == Evict {
+				delete(o.channels, ch)
+				listener.evict()
+			}
+		}
 	}
 }
 
-func (o *LogPump) AddListener(ch chan *Log) {
+func (o *LogPump) AddListener(listener *logListener) {
 	o.Lock()
 	defer o.Unlock()
-	o.channels[ch] = struct{}{}
+	o.channels[listener.ch] = listener
 }
 
 func (o *LogPump) RemoveListener(ch chan *Log) {
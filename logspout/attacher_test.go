@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPump(t *testing.T) (*LogPump, *io.PipeWriter) {
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	stderrWriter.Close()
+	return NewLogPump(stdout, stderr, "abcdef123456", "myapp_v2.web.1", nil), stdoutWriter
+}
+
+func TestLogPumpDropsForSlowListeners(t *testing.T) {
+	pump, w := newTestPump(t)
+	defer w.Close()
+
+	fast := &logListener{ch: make(chan *Log, 10), evicted: make(chan struct{})}
+	slow := &logListener{ch: make(chan *Log, 2), evicted: make(chan struct{})}
+	pump.AddListener(fast)
+	pump.AddListener(slow)
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+
+	for i := 0; i < 5; i++ {
+		select {
+		case l := <-fast.ch:
+			if expected := fmt.Sprintf("line %d", i); l.Data != expected {
+				t.Errorf("expected %q; got %q", expected, l.Data)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the fast listener to get every line")
+		}
+	}
+	// wait for the last line to be sent to both
+	pump.Lock()
+	defer pump.Unlock()
+	if dropped := atomic.LoadUint64(&slow.dropped); dropped != 3 || len(slow.ch) != 2 {
+		t.Errorf("expected the slow listener to keep 2 lines and drop 3; got %d and %d",
+			len(slow.ch), dropped)
+	}
+}
+
+func TestLogPumpEvictsSlowListeners(t *testing.T) {
+	pump, w := newTestPump(t)
+	defer w.Close()
+
+	slow := &logListener{ch: make(chan *Log, 1), policy: Evict, evicted: make(chan struct{})}
+	pump.AddListener(slow)
+	fmt.Fprintln(w, "kept")
+	fmt.Fprintln(w, "overflows")
+
+	select {
+	case <-slow.evicted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the slow listener to be evicted")
+	}
+	pump.Lock()
+	defer pump.Unlock()
+	if len(pump.channels) != 0 {
+		t.Error("expected the evicted listener to be removed")
+	}
+}
+
+func TestAttachManagerSendSkipsStoppedListeners(t *testing.T) {
+	m := &AttachManager{
+		attached: make(map[string]*LogPump),
+		channels: make(map[chan *AttachEvent]chan struct{}),
+	}
+	stopped := make(chan struct{})
+	m.addListener(make(chan *AttachEvent), stopped)
+	close(stopped)
+
+	sent := make(chan bool)
+	go func() {
+		m.send(&AttachEvent{Type: "attach", ID: "abcdef123456"})
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected send not to block on a stopped listener")
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-etcd/etcd"
+)
+
+const (
+	// etcdKeyNotFound is returned for a key that does not exist.
+	etcdKeyNotFound = 100
+	// etcdEventIndexCleared is returned for a watch from an index etcd no longer keeps the
+	// history of.
+	etcdEventIndexCleared = 401
+)
+
+// watchRetryInterval is how long a failed read or watch of etcd waits before it is tried again.
+var watchRetryInterval = 5 * time.Second
+
+// RouteEtcdStore keeps routes in etcd, each as JSON in a key named by its ID under a
+// directory, so every host reading that directory gets the same routes.
+type RouteEtcdStore struct {
+	client *etcd.Client
+	dir    string
+}
+
+func NewRouteEtcdStore(client *etcd.Client, dir string) *RouteEtcdStore {
+	return &RouteEtcdStore{client: client, dir: strings.TrimSuffix(dir, "/")}
+}
+
+func (es *RouteEtcdStore) key(id string) string {
+	return es.dir + "/" + id
+}
+
+func (es *RouteEtcdStore) Get(id string) (*Route, error) {
+	resp, err := es.client.Get(es.key(id), false, false)
+	if err != nil {
+		if isKeyNotFound(err) {
+			return nil, os.ErrNotExist
+		}
+		return nil, err
+	}
+	return decodeRoute(resp.Node)
+}
+
+func (es *RouteEtcdStore) GetAll() ([]*Route, error) {
+	routes, _, err := es.list()
+	return routes, err
+}
+
+// list returns the routes in the store and the etcd index they were read at. Keys that do not
+// hold a valid route are logged and skipped.
+func (es *RouteEtcdStore) list() ([]*Route, uint64, error) {
+	resp, err := es.client.Get(es.dir, false, false)
+	if err != nil {
+		if e, ok := err.(*etcd.EtcdError); ok && e.ErrorCode == etcdKeyNotFound {
+			return nil, e.Index, nil
+		}
+		return nil, 0, err
+	}
+	var routes []*Route
+	for _, node := range resp.Node.Nodes {
+		route, err := decodeRoute(node)
+		if err != nil {
+			log.Println("etcd:", err)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes, resp.EtcdIndex, nil
+}
+
+func (es *RouteEtcdStore) Add(route *Route) error {
+	_, err := es.client.Set(es.key(route.ID), string(marshal(route)), 0)
+	return err
+}
+
+func (es *RouteEtcdStore) Remove(id string) bool {
+	_, err := es.client.Delete(es.key(id), false)
+	return err == nil
+}
+
+// decodeRoute reads the route held by node, taking its ID from the key.
+func decodeRoute(node *etcd.Node) (*Route, error) {
+	route := new(Route)
+	if err := json.Unmarshal([]byte(node.Value), route); err != nil {
+		return nil, fmt.Errorf("%s is not a route: %v", node.Key, err)
+	}
+	route.ID = path.Base(node.Key)
+	if err := validateRoute(route); err != nil {
+		return nil, fmt.Errorf("%s is not a valid route: %v", node.Key, err)
+	}
+	return route, nil
+}
+
+// Watch keeps the routes of rm in step with those in store, whether they are changed through
+// this host or another, until stop is closed. It first loads the routes in store, retrying
+// until it can, and loads them again whenever a watch cannot be resumed. Routes that did not
+// come from store are left alone.
+func (rm *RouteManager) Watch(store *RouteEtcdStore, stop chan bool) {
+	// the IDs of the routes last seen in store
+	known := make(map[string]bool)
+	load := func() (uint64, error) {
+		routes, index, err := store.list()
+		if err != nil {
+			return 0, err
+		}
+		known = rm.sync(routes, known)
+		return index, nil
+	}
+	watchDir(store.client, store.dir, stop, load, func(resp *etcd.Response) bool {
+		if path.Dir(resp.Node.Key) != store.dir {
+			// the directory itself, or something nested in it, changed
+			return true
+		}
+		id := path.Base(resp.Node.Key)
+		switch resp.Action {
+		case "delete", "expire", "compareAndDelete":
+			if known[id] {
+				delete(known, id)
+				rm.Lock()
+				rm.remove(id, false)
+				rm.Unlock()
+			}
+		default:
+			route, err := decodeRoute(resp.Node)
+			if err != nil {
+				log.Println("etcd:", err)
+				return false
+			}
+			known[id] = true
+			rm.put(route)
+		}
+		return false
+	})
+}
+
+// watchDir calls load and then onChange for every change under the etcd directory key, until
+// stop is closed. load returns the etcd index it read at, and the watch resumes from there.
+// load is called again, and the watch started over, whenever onChange returns true or etcd no
+// longer keeps the changes since the index. Failed loads and watches are retried after
+// watchRetryInterval.
+func watchDir(client *etcd.Client, key string, stop chan bool, load func() (uint64, error),
+	onChange func(*etcd.Response) (resync bool)) {
+	var index uint64
+	for {
+		if index == 0 {
+			current, err := load()
+			if err != nil {
+				log.Printf("etcd: could not read %s: %v\n", key, err)
+				if !waitOrStop(stop) {
+					return
+				}
+				continue
+			}
+			index = current + 1
+		}
+
+		resp, err := client.Watch(key, index, true, nil, stop)
+		if err == etcd.ErrWatchStoppedByUser {
+			return
+		}
+		if e, ok := err.(*etcd.EtcdError); ok && e.ErrorCode == etcdEventIndexCleared {
+			// too much has changed since index to resume from it, so start over
+			index = 0
+			continue
+		}
+		if err != nil {
+			log.Printf("etcd: could not watch %s: %v\n", key, err)
+			if !waitOrStop(stop) {
+				return
+			}
+			continue
+		}
+		index = resp.Node.ModifiedIndex + 1
+		if onChange(resp) {
+			index = 0
+		}
+	}
+}
+
+// sync puts routes, and removes the routes in known that are no longer among them. It returns
+// the IDs of routes.
+func (rm *RouteManager) sync(routes []*Route, known map[string]bool) map[string]bool {
+	current := make(map[string]bool)
+	for _, route := range routes {
+		current[route.ID] = true
+		rm.put(route)
+	}
+	rm.Lock()
+	defer rm.Unlock()
+	for id := range known {
+		if !current[id] {
+			rm.remove(id, false)
+		}
+	}
+	return current
+}
+
+func isKeyNotFound(err error) bool {
+	e, ok := err.(*etcd.EtcdError)
+	return ok && e.ErrorCode == etcdKeyNotFound
+}
+
+// waitOrStop waits watchRetryInterval. It returns false if stop was closed meanwhile.
+func waitOrStop(stop chan bool) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(watchRetryInterval):
+		return true
+	}
+}
+
+// loggerRouteID is the ID of the route to deis-logger.
+const loggerRouteID = "deis-logger"
+
+// watchLogger routes all logs to the deis-logger published in /deis/logs/host and
+// /deis/logs/port, and retargets the route whenever the logger moves, until stop is closed.
+// Until the logger has published where it is, it is looked for again every few seconds.
+func (rm *RouteManager) watchLogger(client *etcd.Client, stop chan bool) {
+	load := func() (uint64, error) {
+		addr, index, err := loggerAddr(client)
+		if err != nil {
+			return 0, err
+		}
+		if rm.put(&Route{ID: loggerRouteID, Target: Target{Type: "syslog", Addr: addr}}) {
+			log.Println("routing all to " + addr)
+		}
+		return index, nil
+	}
+	watchDir(client, "/deis/logs", stop, load, func(resp *etcd.Response) bool {
+		switch resp.Node.Key {
+		case "/deis/logs", "/deis/logs/host", "/deis/logs/port":
+			return true
+		}
+		return false
+	})
+}
+
+// loggerAddr returns the address deis-logger is published at, and the etcd index it was read
+// at.
+func loggerAddr(client *etcd.Client) (string, uint64, error) {
+	host, err := client.Get("/deis/logs/host", false, false)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := client.Get("/deis/logs/port", false, false)
+	if err != nil {
+		return "", 0, err
+	}
+	return net.JoinHostPort(host.Node.Value, port.Node.Value), host.EtcdIndex, nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-etcd/etcd"
+)
+
+// fakeEtcd keeps keys in memory at index 7, and answers watches from events.
+type fakeEtcd struct {
+	mu     sync.Mutex
+	keys   map[string]string
+	events chan string
+	done   chan bool
+}
+
+func newFakeEtcd() (*fakeEtcd, *etcd.Client, func()) {
+	f := &fakeEtcd{keys: make(map[string]string), events: make(chan string), done: make(chan bool)}
+	server := httptest.NewServer(f)
+	return f, etcd.NewClient([]string{server.URL}), func() {
+		close(f.done)
+		server.Close()
+	}
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Etcd-Index", "7")
+	key := strings.TrimPrefix(r.URL.Path, "/v2/keys")
+	if r.URL.Query().Get("wait") == "true" {
+		select {
+		case event := <-f.events:
+			fmt.Fprint(w, event)
+		case <-f.done:
+		}
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case "PUT":
+		r.ParseForm()
+		f.keys[key] = r.Form.Get("value")
+		fmt.Fprint(w, nodeJSON("set", key, f.keys[key]))
+		return
+	case "DELETE":
+		if _, ok := f.keys[key]; ok {
+			delete(f.keys, key)
+			fmt.Fprint(w, nodeJSON("delete", key, ""))
+			return
+		}
+	default:
+		if value, ok := f.keys[key]; ok {
+			fmt.Fprint(w, nodeJSON("get", key, value))
+			return
+		}
+		var nodes []string
+		for k, value := range f.keys {
+			if strings.HasPrefix(k, key+"/") {
+				nodes = append(nodes, fmt.Sprintf(`{"key":%q,"value":%q}`, k, value))
+			}
+		}
+		if len(nodes) > 0 {
+			sort.Strings(nodes)
+			fmt.Fprintf(w, `{"action":"get","node":{"key":%q,"dir":true,"nodes":[%s]}}`, key,
+				strings.Join(nodes, ","))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, `{"errorCode":100,"message":"Key not found","cause":%q,"index":7}`, key)
+}
+
+func (f *fakeEtcd) set(key, value string) {
+	f.mu.Lock()
+	f.keys[key] = value
+	f.mu.Unlock()
+}
+
+func nodeJSON(action, key, value string) string {
+	return fmt.Sprintf(`{"action":%q,"node":{"key":%q,"value":%q,"modifiedIndex":8}}`, action, key, value)
+}
+
+func routeJSON(t *testing.T, route *Route) string {
+	b, err := json.Marshal(route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRouteEtcdStore(t *testing.T) {
+	f, client, stop := newFakeEtcd()
+	defer stop()
+	store := NewRouteEtcdStore(client, "/deis/logspout/routes/")
+
+	if routes, err := store.GetAll(); err != nil || len(routes) != 0 {
+		t.Errorf("expected no routes; got %v, %v", routes, err)
+	}
+	route := &Route{ID: "abc", Target: Target{Type: "syslog", Addr: "127.0.0.1:514"}}
+	if err := store.Add(route); err != nil {
+		t.Fatal(err)
+	}
+	// a hand-written key that is not a route is skipped
+	f.set("/deis/logspout/routes/broken", `{"target":{"type":"smoke-signal"}}`)
+
+	stored, err := store.Get("abc")
+	if err != nil || stored.ID != "abc" || stored.Target != route.Target {
+		t.Errorf("expected the route back; got %+v, %v", stored, err)
+	}
+	if routes, err := store.GetAll(); err != nil || len(routes) != 1 || routes[0].ID != "abc" {
+		t.Errorf("expected only the valid route; got %v, %v", routes, err)
+	}
+	if !store.Remove("abc") {
+		t.Error("expected the route to be removed")
+	}
+	if _, err = store.Get("abc"); err != os.ErrNotExist {
+		t.Errorf("expected the route to be gone; got %v", err)
+	}
+}
+
+func waitForRoute(t *testing.T, rm *RouteManager, id string, check func(*Route) bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		route, _ := rm.Get(id)
+		if check(route) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for route %s; got %+v", id, route)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRouteManagerWatch(t *testing.T) {
+	f, client, stopEtcd := newFakeEtcd()
+	defer stopEtcd()
+	first, second := newUDPSink(t), newUDPSink(t)
+	defer first.close()
+	defer second.close()
+	store := NewRouteEtcdStore(client, "/deis/logspout/routes")
+	f.set("/deis/logspout/routes/a", routeJSON(t, &Route{Target: Target{Type: "syslog", Addr: first.addr}}))
+
+	rm, pump, write := newTestRouter(t)
+	rm.Add(&Route{ID: "local", Target: Target{Type: "syslog", Addr: second.addr}})
+	defer rm.Remove("local")
+	stop := make(chan bool)
+	stopped := make(chan bool)
+	go func() {
+		rm.Watch(store, stop)
+		close(stopped)
+	}()
+	waitForRoute(t, rm, "a", func(r *Route) bool { return r != nil })
+	waitForListeners(t, pump, 2)
+	write("loaded")
+	first.expect(t, "loaded")
+
+	f.events <- nodeJSON("set", "/deis/logspout/routes/a",
+		routeJSON(t, &Route{Target: Target{Type: "syslog", Addr: second.addr, AppendTag: ".a"}}))
+	waitForRoute(t, rm, "a", func(r *Route) bool { return r != nil && r.Target.Addr == second.addr })
+
+	f.events <- nodeJSON("delete", "/deis/logspout/routes/a", "")
+	waitForRoute(t, rm, "a", func(r *Route) bool { return r == nil })
+	waitForListeners(t, pump, 1)
+	// routes that did not come from etcd are kept
+	if route, _ := rm.Get("local"); route == nil {
+		t.Error("expected the local route to be kept")
+	}
+
+	close(stop)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the watch to stop")
+	}
+}
+
+func TestRouteManagerWatchLogger(t *testing.T) {
+	watchRetryInterval = 10 * time.Millisecond
+	defer func() { watchRetryInterval = 5 * time.Second }()
+	f, client, stopEtcd := newFakeEtcd()
+	defer stopEtcd()
+	first, second := newUDPSink(t), newUDPSink(t)
+	defer first.close()
+	defer second.close()
+
+	rm, pump, write := newTestRouter(t)
+	stop := make(chan bool)
+	stopped := make(chan bool)
+	go func() {
+		rm.watchLogger(client, stop)
+		close(stopped)
+	}()
+	// the logger has yet to publish where it is
+	time.Sleep(50 * time.Millisecond)
+	if route, _ := rm.Get(loggerRouteID); route != nil {
+		t.Fatalf("expected no route before the logger is published; got %+v", route)
+	}
+
+	host, port, _ := net.SplitHostPort(first.addr)
+	f.set("/deis/logs/host", host)
+	f.set("/deis/logs/port", port)
+	waitForRoute(t, rm, loggerRouteID, func(r *Route) bool { return r != nil && r.Target.Addr == first.addr })
+	waitForListeners(t, pump, 1)
+	write("first")
+	first.expect(t, "first")
+
+	_, port, _ = net.SplitHostPort(second.addr)
+	f.set("/deis/logs/port", port)
+	f.events <- nodeJSON("set", "/deis/logs/port", port)
+	waitForRoute(t, rm, loggerRouteID, func(r *Route) bool { return r != nil && r.Target.Addr == second.addr })
+	write("second")
+	second.expect(t, "second")
+
+	close(stop)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the watch to stop")
+	}
+	rm.Remove(loggerRouteID)
+}
+
+func TestRouteManagerWatchDeletesDetachedRoute(t *testing.T) {
+	f, client, stopEtcd := newFakeEtcd()
+	defer stopEtcd()
+	sink := newUDPSink(t)
+	defer sink.close()
+	store := NewRouteEtcdStore(client, "/deis/logspout/routes")
+
+	rm, pump, _ := newTestRouter(t)
+	f.set("/deis/logspout/routes/a", routeJSON(t, &Route{Source: &Source{ID: pump.ID},
+		Target: Target{Type: "syslog", Addr: sink.addr}}))
+	stop := make(chan bool)
+	stopped := make(chan bool)
+	go func() {
+		rm.Watch(store, stop)
+		close(stopped)
+	}()
+	waitForListeners(t, pump, 1)
+	// the container exits, so the listener of the route returns
+	rm.attacher.send(&AttachEvent{Type: "detach", ID: pump.ID})
+	waitForListeners(t, pump, 0)
+
+	f.events <- nodeJSON("delete", "/deis/logspout/routes/a", "")
+	waitForRoute(t, rm, "a", func(r *Route) bool { return r == nil })
+	// the watch goes on
+	f.events <- nodeJSON("set", "/deis/logspout/routes/b",
+		routeJSON(t, &Route{Target: Target{Type: "syslog", Addr: sink.addr}}))
+	waitForRoute(t, rm, "b", func(r *Route) bool { return r != nil })
+
+	close(stop)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the watch to stop")
+	}
+	rm.Remove("b")
+}
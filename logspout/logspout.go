@@ -3,17 +3,14 @@ package main
 import (
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/coreos/go-etcd/etcd"
-	dtime "github.com/deis/deis/pkg/time"
 	"github.com/fsouza/go-dockerclient"
 	"github.com/go-martini/martini"
 	"golang.org/x/net/websocket"
@@ -57,30 +54,6 @@ func (c Colorizer) Get(key string) string {
 	return "\x1b[" + bright + "3" + strconv.Itoa(7-(i%7)) + "m"
 }
 
-func syslogStreamer(target Target, types []string, logstream chan *Log) {
-	typestr := "," + strings.Join(types, ",") + ","
-	for logline := range logstream {
-		if typestr != ",," && !strings.Contains(typestr, logline.Type) {
-			continue
-		}
-		tag, pid := getLogName(logline.Name)
-		addr, err := net.ResolveUDPAddr("udp", target.Addr)
-		assert(err, "syslog")
-		conn, err := net.DialUDP("udp", nil, addr)
-		assert(err, "syslog")
-		// bump up the packet size for large log lines
-		assert(conn.SetWriteBuffer(1048576), "syslog")
-		// HACK: Go's syslog package hardcodes the log format, so let's send our own message
-		_, err = fmt.Fprintf(conn,
-			"%s %s[%s]: %s",
-			time.Now().Format(getopt("DATETIME_FORMAT", dtime.DeisDatetimeFormat)),
-			tag,
-			pid,
-			logline.Data)
-		assert(err, "syslog")
-	}
-}
-
 // getLogName returns a custom tag and PID for containers that
 // match Deis' specific application name format. Otherwise,
 // it returns the original name and 1 as the PID.
@@ -158,26 +131,24 @@ func main() {
 	port := getopt("PORT", "8000")
 	endpoint := getopt("DOCKER_HOST", "unix:///var/run/docker.sock")
 	routespath := getopt("ROUTESPATH", "/var/lib/logspout")
+	if n, err := strconv.Atoi(getopt("LISTENER_BUFFER", "1024")); err == nil && n > 0 {
+		ListenerBuffer = n
+	}
+	syslogTLSCA = getopt("SYSLOG_TLS_CA", "")
 
 	client, err := docker.NewClient(endpoint)
 	assert(err, "docker")
 	attacher := NewAttachManager(client)
 	router := NewRouteManager(attacher)
 
-	// HACK: if we are connecting to etcd, get the logger's connection
-	// details from there
+	var etcdClient *etcd.Client
 	if etcdHost := os.Getenv("ETCD_HOST"); etcdHost != "" {
 		connectionString := []string{"http://" + etcdHost + ":4001"}
 		debug("etcd:", connectionString[0])
-		etcd := etcd.NewClient(connectionString)
-		etcd.SetDialTimeout(3 * time.Second)
-		hostResp, err := etcd.Get("/deis/logs/host", false, false)
-		assert(err, "url")
-		portResp, err := etcd.Get("/deis/logs/port", false, false)
-		assert(err, "url")
-		host := fmt.Sprintf("%s:%s", hostResp.Node.Value, portResp.Node.Value)
-		log.Println("routing all to " + host)
-		router.Add(&Route{Target: Target{Type: "syslog", Addr: host}})
+		etcdClient = etcd.NewClient(connectionString)
+		etcdClient.SetDialTimeout(3 * time.Second)
+		// route all logs to deis-logger, wherever it is published
+		go router.watchLogger(etcdClient, nil)
 	}
 
 	if len(os.Args) > 1 {
@@ -187,7 +158,13 @@ func main() {
 		router.Add(&Route{Target: Target{Type: u.Scheme, Addr: u.Host}})
 	}
 
-	if _, err := os.Stat(routespath); err == nil {
+	if etcdClient != nil {
+		// routes kept in etcd apply to every host, and are loaded as soon as etcd can be read
+		store := NewRouteEtcdStore(etcdClient, getopt("ETCD_ROUTES", "/deis/logspout/routes"))
+		log.Println("loading and persisting routes in etcd under " + store.dir)
+		router.persistor = store
+		go router.Watch(store, nil)
+	} else if _, err := os.Stat(routespath); err == nil {
 		log.Println("loading and persisting routes in " + routespath)
 		assert(router.Load(RouteFileStore(routespath)), "persistor")
 	}
@@ -210,12 +187,13 @@ func main() {
 			return
 		}
 
-		logstream := make(chan *Log)
+		// a client that cannot keep up is disconnected rather than sent a stream with gaps
+		logstream := make(chan *Log, ListenerBuffer)
 		defer close(logstream)
 
 		var closer <-chan bool
 		if req.Header.Get("Upgrade") == "websocket" {
-			closerBi := make(chan bool)
+			closerBi := make(chan bool, 1)
 			go websocketStreamer(w, req, logstream, closerBi)
 			closer = closerBi
 		} else {
@@ -223,7 +201,7 @@ func main() {
 			closer = w.(http.CloseNotifier).CloseNotify()
 		}
 
-		attacher.Listen(source, logstream, closer)
+		attacher.Listen(source, logstream, closer, Evict)
 	})
 
 	m.Get("/routes", func(w http.ResponseWriter, req *http.Request) {
@@ -238,13 +216,35 @@ func main() {
 			return http.StatusBadRequest, "Bad request: " + err.Error()
 		}
 
-		// TODO: validate?
+		if err := validateRoute(route); err != nil {
+			return http.StatusBadRequest, "Bad request: " + err.Error()
+		}
 		router.Add(route)
 
 		w.Header().Add("Content-Type", "application/json")
 		return http.StatusCreated, string(append(marshal(route), '\n'))
 	})
 
+	m.Put("/routes/:id", func(w http.ResponseWriter, req *http.Request, params martini.Params) (int, string) {
+		route := new(Route)
+		if err := unmarshal(req.Body, route); err != nil {
+			return http.StatusBadRequest, "Bad request: " + err.Error()
+		}
+		if route.ID != "" && route.ID != params["id"] {
+			return http.StatusBadRequest, "Bad request: id: the id of a route cannot be changed"
+		}
+		route.ID = params["id"]
+		if err := validateRoute(route); err != nil {
+			return http.StatusBadRequest, "Bad request: " + err.Error()
+		}
+		if err := router.Update(route); err != nil {
+			return http.StatusNotFound, "Not found: " + route.ID
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		return http.StatusOK, string(append(marshal(route), '\n'))
+	})
+
 	m.Get("/routes/:id", func(w http.ResponseWriter, req *http.Request, params martini.Params) {
 		route, _ := router.Get(params["id"])
 		if route == nil {
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// multilineMaxSize caps a joined event so it still fits in a syslog packet.
+const multilineMaxSize = 65000
+
+// Multiline joins continuation lines, such as those of a stack trace, to the line before them
+// so they are routed as one log event.
+type Multiline struct {
+	Pattern *regexp.Regexp
+	Timeout time.Duration
+}
+
+// getMultiline returns the multi-line mode set in a container's environment, falling back to
+// the one set in logspout's. It is off unless LOG_MULTILINE_PATTERN is set.
+func getMultiline(env []string) *Multiline {
+	vars := make(map[string]string)
+	for _, kv := range env {
+		if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+			vars[parts[0]] = parts[1]
+		}
+	}
+	lookup := func(name, dfault string) string {
+		if value := vars[name]; value != "" {
+			return value
+		}
+		return getopt(name, dfault)
+	}
+
+	pattern := lookup("LOG_MULTILINE_PATTERN", "")
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Println("multiline: invalid LOG_MULTILINE_PATTERN:", err)
+		return nil
+	}
+	timeout, err := time.ParseDuration(lookup("LOG_MULTILINE_TIMEOUT", "500ms"))
+	if err != nil {
+		log.Println("multiline: invalid LOG_MULTILINE_TIMEOUT:", err)
+		timeout = 500 * time.Millisecond
+	}
+	return &Multiline{Pattern: re, Timeout: timeout}
+}
+
+// Join reads lines until the channel is closed and sends events made of a line and the
+// continuation lines after it. An event is sent once a line that does not continue it arrives,
+// or no line has arrived for the timeout.
+func (ml *Multiline) Join(lines <-chan string, send func(string)) {
+	var event []string
+	var size int
+	var timeout <-chan time.Time
+	flush := func() {
+		if len(event) > 0 {
+			send(strings.Join(event, "\n"))
+		}
+		event, size, timeout = nil, 0, nil
+	}
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			if !ml.Pattern.MatchString(line) || size+len(line) > multilineMaxSize {
+				flush()
+			}
+			event = append(event, line)
+			size += len(line) + 1
+			timeout = time.After(ml.Timeout)
+		case <-timeout:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func join(ml *Multiline, lines ...string) []string {
+	in := make(chan string)
+	var events []string
+	done := make(chan bool)
+	go func() {
+		ml.Join(in, func(event string) { events = append(events, event) })
+		close(done)
+	}()
+	for _, line := range lines {
+		in <- line
+	}
+	close(in)
+	<-done
+	return events
+}
+
+func TestMultilineJoin(t *testing.T) {
+	ml := &Multiline{Pattern: regexp.MustCompile(`^\s|^Caused by:`), Timeout: time.Minute}
+	events := join(ml,
+		"  orphaned continuation",
+		"Exception in thread \"main\" java.lang.RuntimeException",
+		"\tat Main.main(Main.java:5)",
+		"Caused by: java.lang.NullPointerException",
+		"\t... 1 more",
+		"next line",
+	)
+	expected := []string{
+		"  orphaned continuation",
+		"Exception in thread \"main\" java.lang.RuntimeException\n\tat Main.main(Main.java:5)\n" +
+			"Caused by: java.lang.NullPointerException\n\t... 1 more",
+		"next line",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %q; got %q", expected, events)
+	}
+}
+
+func TestMultilineTimeout(t *testing.T) {
+	ml := &Multiline{Pattern: regexp.MustCompile(`^\s`), Timeout: 10 * time.Millisecond}
+	in := make(chan string)
+	sent := make(chan string, 2)
+	go ml.Join(in, func(event string) { sent <- event })
+	in <- "Traceback (most recent call last):"
+	in <- "  File \"app.py\", line 1"
+	select {
+	case event := <-sent:
+		if event != "Traceback (most recent call last):\n  File \"app.py\", line 1" {
+			t.Errorf("unexpected event; got %q", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected the event to be sent after the timeout")
+	}
+	close(in)
+}
+
+func TestGetMultiline(t *testing.T) {
+	if ml := getMultiline([]string{"PATH=/bin"}); ml != nil {
+		t.Errorf("expected multi-line mode to be off by default; got %+v", ml)
+	}
+	ml := getMultiline([]string{`LOG_MULTILINE_PATTERN=^\s`, "LOG_MULTILINE_TIMEOUT=2s"})
+	if ml == nil || ml.Pattern.String() != `^\s` || ml.Timeout != 2*time.Second {
+		t.Errorf("unexpected multi-line mode; got %+v", ml)
+	}
+	if ml := getMultiline([]string{"LOG_MULTILINE_PATTERN=("}); ml != nil {
+		t.Errorf("expected an invalid pattern to turn multi-line mode off; got %+v", ml)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -66,40 +67,110 @@ func (rm *RouteManager) GetAll() ([]*Route, error) {
 func (rm *RouteManager) Add(route *Route) error {
 	rm.Lock()
 	defer rm.Unlock()
+	rm.add(route, true)
+	return nil
+}
+
+// add starts route, saving it in the persistor if persist is set. It must be called with rm
+// locked.
+func (rm *RouteManager) add(route *Route, persist bool) {
 	if route.ID == "" {
 		h := sha1.New()
 		io.WriteString(h, strconv.Itoa(int(time.Now().UnixNano())))
 		route.ID = fmt.Sprintf("%x", h.Sum(nil))[:12]
 	}
 	route.closer = make(chan bool)
+	route.retarget = make(chan Target, 1)
 	rm.routes[route.ID] = route
 	types := []string{}
 	if route.Source != nil {
 		types = append(types, route.Source.Types...)
 	}
 	go func() {
-		logstream := make(chan *Log)
+		logstream := make(chan *Log, ListenerBuffer)
 		defer close(logstream)
-		go syslogStreamer(route.Target, types, logstream)
-		rm.attacher.Listen(route.Source, logstream, route.closer)
+		go syslogStreamer(route.Target, types, logstream, route.retarget)
+		rm.attacher.Listen(route.Source, logstream, route.closer, DropLines)
 	}()
-	if rm.persistor != nil {
+	if persist && rm.persistor != nil {
 		if err := rm.persistor.Add(route); err != nil {
 			log.Println("persistor:", err)
 		}
 	}
+}
+
+// Update replaces the route with the ID of route. A route whose source is unchanged keeps
+// streaming and only switches to the new target, otherwise it is restarted.
+func (rm *RouteManager) Update(route *Route) error {
+	rm.Lock()
+	defer rm.Unlock()
+	return rm.update(route, true)
+}
+
+// update replaces a route, saving it in the persistor if persist is set. It must be called
+// with rm locked.
+func (rm *RouteManager) update(route *Route, persist bool) error {
+	existing, ok := rm.routes[route.ID]
+	if !ok {
+		return os.ErrNotExist
+	}
+	if !reflect.DeepEqual(existing.Source, route.Source) {
+		// the new route overwrites the old one in the persistor, so it is not removed there
+		rm.remove(route.ID, false)
+		rm.add(route, persist)
+		return nil
+	}
+	// a copy, as the old route may still be read by callers of Get
+	updated := *existing
+	updated.Target = route.Target
+	rm.routes[route.ID] = &updated
+	// replace a target the streamer has yet to switch to
+	select {
+	case <-existing.retarget:
+	default:
+	}
+	existing.retarget <- route.Target
+	if persist && rm.persistor != nil {
+		if err := rm.persistor.Add(&updated); err != nil {
+			log.Println("persistor:", err)
+		}
+	}
 	return nil
 }
 
+// put adds route, or updates the route with its ID unless that already has the same source
+// and target. It reports whether anything changed. The persistor is left alone.
+func (rm *RouteManager) put(route *Route) bool {
+	rm.Lock()
+	defer rm.Unlock()
+	existing, ok := rm.routes[route.ID]
+	switch {
+	case !ok:
+		rm.add(route, false)
+	case !reflect.DeepEqual(existing.Source, route.Source) || existing.Target != route.Target:
+		rm.update(route, false)
+	default:
+		return false
+	}
+	return true
+}
+
 func (rm *RouteManager) Remove(id string) bool {
 	rm.Lock()
 	defer rm.Unlock()
+	return rm.remove(id, true)
+}
+
+// remove stops the route with id, removing it from the persistor if persist is set. It must
+// be called with rm locked. The closer of the route is closed rather than sent to, as its
+// listener may already have returned after its container detached.
+func (rm *RouteManager) remove(id string, persist bool) bool {
 	route, ok := rm.routes[id]
 	if ok && route.closer != nil {
-		route.closer <- true
+		close(route.closer)
 	}
 	delete(rm.routes, id)
-	if rm.persistor != nil {
+	if persist && rm.persistor != nil {
 		rm.persistor.Remove(id)
 	}
 	return ok
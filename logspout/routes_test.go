@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestRouter returns a router whose attacher has one container attached.
+func newTestRouter(t *testing.T) (*RouteManager, *LogPump, func(string)) {
+	pump, w := newTestPump(t)
+	attacher := &AttachManager{
+		attached: map[string]*LogPump{pump.ID: pump},
+		channels: make(map[chan *AttachEvent]chan struct{}),
+	}
+	return NewRouteManager(attacher), pump, func(line string) { fmt.Fprintln(w, line) }
+}
+
+func waitForListeners(t *testing.T, pump *LogPump, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pump.Lock()
+		listeners := len(pump.channels)
+		pump.Unlock()
+		if listeners == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d listeners; got %d", n, listeners)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRouteManagerUpdateRetargets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	first, second := newUDPSink(t), newUDPSink(t)
+	defer first.close()
+	defer second.close()
+
+	rm, pump, write := newTestRouter(t)
+	rm.persistor = RouteFileStore(dir)
+	rm.Add(&Route{ID: "route", Target: Target{Type: "syslog", Addr: first.addr}})
+	defer rm.Remove("route")
+	waitForListeners(t, pump, 1)
+	write("before")
+	first.expect(t, "before")
+
+	if err = rm.Update(&Route{ID: "route", Target: Target{Type: "syslog", Addr: second.addr}}); err != nil {
+		t.Fatal(err)
+	}
+	write("after")
+	second.expect(t, "after")
+
+	route, _ := rm.Get("route")
+	if route.Target.Addr != second.addr {
+		t.Errorf("expected the route to be updated; got %+v", route.Target)
+	}
+	if stored, err := RouteFileStore(dir).Get("route"); err != nil || stored.Target.Addr != second.addr {
+		t.Errorf("expected the update to be persisted; got %+v, %v", stored, err)
+	}
+	if err = rm.Update(&Route{ID: "missing", Target: route.Target}); err != os.ErrNotExist {
+		t.Errorf("expected an error for a missing route; got %v", err)
+	}
+}
+
+func TestRouteManagerUpdateRestartsOnNewSource(t *testing.T) {
+	sink := newUDPSink(t)
+	defer sink.close()
+	rm, pump, write := newTestRouter(t)
+	rm.Add(&Route{ID: "route", Target: Target{Type: "syslog", Addr: sink.addr}})
+	defer rm.Remove("route")
+	waitForListeners(t, pump, 1)
+
+	rm.Update(&Route{ID: "route", Source: &Source{Name: "other"}, Target: Target{Type: "syslog", Addr: sink.addr}})
+	waitForListeners(t, pump, 0)
+	write("not routed")
+	select {
+	case m := <-sink.messages:
+		t.Errorf("expected the route to stop taking the container's lines; got %q", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRouteManagerRemoveAfterDetach(t *testing.T) {
+	sink := newUDPSink(t)
+	defer sink.close()
+	rm, pump, _ := newTestRouter(t)
+	rm.Add(&Route{ID: "route", Source: &Source{ID: pump.ID}, Target: Target{Type: "syslog", Addr: sink.addr}})
+	waitForListeners(t, pump, 1)
+	// the listener of a route on a single container returns once it detaches
+	rm.attacher.send(&AttachEvent{Type: "detach", ID: pump.ID})
+	waitForListeners(t, pump, 0)
+
+	done := make(chan bool)
+	go func() {
+		rm.Update(&Route{ID: "route", Source: &Source{Name: "other"}, Target: Target{Type: "syslog", Addr: sink.addr}})
+		if !rm.Remove("route") {
+			t.Error("expected the route to be removed")
+		}
+		if rm.Remove("route") {
+			t.Error("expected a second remove to find nothing")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the route to be updated and removed")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	dtime "github.com/deis/deis/pkg/time"
+)
+
+// Delays before reconnecting to a syslog target, doubling from the minimum up to the maximum.
+// Lines routed while waiting are dropped, so a target that is down never holds up the others.
+var (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// syslogTimeout bounds connecting to a syslog target and writing a line to it.
+var syslogTimeout = 10 * time.Second
+
+// syslogTLSCA is a file of PEM encoded certificates that syslog+tls targets are verified
+// against instead of the system's, set with SYSLOG_TLS_CA. It is read on every connect.
+var syslogTLSCA string
+
+// syslogWriter keeps a connection to a syslog target open across lines, reconnecting with
+// backoff when it fails. syslog targets take UDP packets, syslog+tcp and syslog+tls take
+// octet-counted frames as in RFC 6587.
+type syslogWriter struct {
+	target  Target
+	conn    net.Conn
+	delay   time.Duration
+	retryAt time.Time
+	dropped int
+}
+
+func newSyslogWriter(target Target) (*syslogWriter, error) {
+	switch target.Type {
+	case "syslog", "syslog+tcp", "syslog+tls":
+		return &syslogWriter{target: target}, nil
+	}
+	return nil, fmt.Errorf("unknown target type %q, use syslog, syslog+tcp or syslog+tls", target.Type)
+}
+
+func (w *syslogWriter) dial() (net.Conn, error) {
+	switch w.target.Type {
+	case "syslog+tcp":
+		return net.DialTimeout("tcp", w.target.Addr, syslogTimeout)
+	case "syslog+tls":
+		config, err := tlsConfig(w.target.Addr)
+		if err != nil {
+			return nil, err
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: syslogTimeout}, "tcp", w.target.Addr, config)
+	}
+	conn, err := net.DialTimeout("udp", w.target.Addr, syslogTimeout)
+	if err != nil {
+		return nil, err
+	}
+	// bump up the packet size for large log lines
+	if err = conn.(*net.UDPConn).SetWriteBuffer(1048576); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// tlsConfig verifies the syslog+tls target at addr by its host name, against the certificates
+// in syslogTLSCA if set.
+func tlsConfig(addr string) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{ServerName: host}
+	if syslogTLSCA == "" {
+		return config, nil
+	}
+	pem, err := ioutil.ReadFile(syslogTLSCA)
+	if err != nil {
+		return nil, err
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", syslogTLSCA)
+	}
+	return config, nil
+}
+
+// Write sends a message, connecting first if needed. Errors are logged, and the message is
+// dropped while the target is backed off from.
+func (w *syslogWriter) Write(message string) {
+	if w.conn == nil {
+		if time.Now().Before(w.retryAt) {
+			w.dropped++
+			return
+		}
+		conn, err := w.dial()
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		if w.dropped > 0 {
+			log.Printf("syslog: reconnected to %s, %d lines dropped\n", w.target.Addr, w.dropped)
+		}
+		w.conn, w.delay, w.dropped = conn, 0, 0
+	}
+	if w.target.Type != "syslog" {
+		// frames may hold newlines of multi-line events
+		message = fmt.Sprintf("%d %s", len(message), message)
+		w.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+	}
+	if _, err := w.conn.Write([]byte(message)); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.fail(err)
+	}
+}
+
+// fail logs err, drops the current message and backs off before the next attempt.
+func (w *syslogWriter) fail(err error) {
+	log.Printf("syslog: %s: %v\n", w.target.Addr, err)
+	w.dropped++
+	if w.delay *= 2; w.delay < minReconnectDelay {
+		w.delay = minReconnectDelay
+	} else if w.delay > maxReconnectDelay {
+		w.delay = maxReconnectDelay
+	}
+	w.retryAt = time.Now().Add(w.delay)
+}
+
+func (w *syslogWriter) Close() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// syslogStreamer sends the lines of logstream of the given types to target, and to the targets
+// sent on retarget after it, until logstream is closed.
+func syslogStreamer(target Target, types []string, logstream chan *Log, retarget <-chan Target) {
+	var w *syslogWriter
+	connect := func(target Target) {
+		if w != nil {
+			w.Close()
+		}
+		var err error
+		if w, err = newSyslogWriter(target); err != nil {
+			// keep reading so the route does not hold up the others
+			log.Println("syslog:", err)
+		}
+	}
+	connect(target)
+	defer func() {
+		if w != nil {
+			w.Close()
+		}
+	}()
+	typestr := "," + strings.Join(types, ",") + ","
+	for {
+		select {
+		case target := <-retarget:
+			connect(target)
+		case logline, ok := <-logstream:
+			if !ok {
+				return
+			}
+			if w == nil || typestr != ",," && !strings.Contains(typestr, logline.Type) {
+				continue
+			}
+			tag, pid := getLogName(logline.Name)
+			// HACK: Go's syslog package hardcodes the log format, so let's send our own message
+			w.Write(fmt.Sprintf("%s %s[%s]: %s",
+				time.Now().Format(getopt("DATETIME_FORMAT", dtime.DeisDatetimeFormat)),
+				tag,
+				pid,
+				logline.Data))
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syslogSink receives messages in process, as UDP packets or as octet-counted TCP or TLS
+// frames.
+type syslogSink struct {
+	addr     string
+	messages chan string
+	close    func()
+}
+
+func newUDPSink(t *testing.T) *syslogSink {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &syslogSink{addr: conn.LocalAddr().String(), messages: make(chan string, 10),
+		close: func() { conn.Close() }}
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			s.messages <- string(buf[:n])
+		}
+	}()
+	return s
+}
+
+func newTCPSink(t *testing.T, addr string) *syslogSink {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newStreamSink(l)
+}
+
+// newTLSSink listens on a local port with a certificate for 127.0.0.1, which it writes to the
+// returned file for clients to trust.
+func newTLSSink(t *testing.T) (*syslogSink, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyDER := x509.MarshalPKCS1PrivateKey(key)
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "logspout-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(certPEM)
+	f.Close()
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newStreamSink(l), f.Name()
+}
+
+// newStreamSink reads octet-counted frames from the connections accepted by l.
+func newStreamSink(l net.Listener) *syslogSink {
+	s := &syslogSink{addr: l.Addr().String(), messages: make(chan string, 10)}
+	conns := make(chan net.Conn, 10)
+	s.close = func() {
+		l.Close()
+		close(conns)
+		for conn := range conns {
+			conn.Close()
+		}
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					count, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+					if err != nil {
+						return
+					}
+					buf := make([]byte, n)
+					if _, err := io.ReadFull(r, buf); err != nil {
+						return
+					}
+					s.messages <- string(buf)
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+func (s *syslogSink) expect(t *testing.T, suffix string) {
+	select {
+	case m := <-s.messages:
+		if !strings.HasSuffix(m, suffix) {
+			t.Errorf("expected a message ending in %q; got %q", suffix, m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %q", suffix)
+	}
+}
+
+func TestSyslogWriterUDP(t *testing.T) {
+	sink := newUDPSink(t)
+	defer sink.close()
+	w, err := newSyslogWriter(Target{Type: "syslog", Addr: sink.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write("one")
+	conn := w.conn
+	w.Write("two")
+	sink.expect(t, "one")
+	sink.expect(t, "two")
+	if w.conn != conn {
+		t.Error("expected the connection to be kept open")
+	}
+}
+
+func TestSyslogWriterTCPReconnects(t *testing.T) {
+	minReconnectDelay = 10 * time.Millisecond
+	defer func() { minReconnectDelay = time.Second }()
+	sink := newTCPSink(t, "127.0.0.1:0")
+	w, err := newSyslogWriter(Target{Type: "syslog+tcp", Addr: sink.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write("line 1\n  continued")
+	sink.expect(t, "line 1\n  continued")
+
+	sink.close()
+	// the first write after the close may still succeed, the next ones fail and back off
+	for i := 0; i < 3 && w.conn != nil; i++ {
+		w.Write("lost")
+		time.Sleep(10 * time.Millisecond)
+	}
+	if w.conn != nil {
+		t.Fatal("expected the connection to be dropped")
+	}
+
+	sink = newTCPSink(t, sink.addr)
+	defer sink.close()
+	deadline := time.Now().Add(5 * time.Second)
+	for w.conn == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		w.Write("back")
+	}
+	sink.expect(t, "back")
+}
+
+func TestSyslogWriterTLSCA(t *testing.T) {
+	sink, ca := newTLSSink(t)
+	defer sink.close()
+	defer os.Remove(ca)
+	defer func() { syslogTLSCA = "" }()
+
+	w, err := newSyslogWriter(Target{Type: "syslog+tls", Addr: sink.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// the sink's certificate is not trusted by the system
+	if _, err := w.dial(); err == nil {
+		t.Fatal("expected an untrusted certificate to be refused")
+	}
+
+	syslogTLSCA = ca
+	w.Write("secure")
+	sink.expect(t, "secure")
+}
+
+func TestSyslogStreamerSurvivesBadTargets(t *testing.T) {
+	logstream := make(chan *Log)
+	done := make(chan bool)
+	go func() {
+		syslogStreamer(Target{Type: "syslog", Addr: "no-such-host.invalid:514"}, nil, logstream, nil)
+		close(done)
+	}()
+	logstream <- &Log{Name: "myapp_v2.web.1", Data: "dropped"}
+	close(logstream)
+	<-done
+
+	if _, err := newSyslogWriter(Target{Type: "gopher", Addr: "localhost:514"}); err == nil {
+		t.Error("expected an error for an unknown target type")
+	}
+}
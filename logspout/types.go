@@ -21,10 +21,11 @@ type Log struct {
 }
 
 type Route struct {
-	ID     string  `json:"id"`
-	Source *Source `json:"source,omitempty"`
-	Target Target  `json:"target"`
-	closer chan bool
+	ID       string  `json:"id"`
+	Source   *Source `json:"source,omitempty"`
+	Target   Target  `json:"target"`
+	closer   chan bool
+	retarget chan Target
 }
 
 type Source struct {
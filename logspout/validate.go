@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var containerIDRegex = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+// validationError lists everything wrong with a route.
+type validationError []string
+
+func (e validationError) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// validateRoute checks the target and source of a route, returning a validationError if they
+// are invalid.
+func validateRoute(route *Route) error {
+	var errs validationError
+	if _, err := newSyslogWriter(route.Target); err != nil {
+		errs = append(errs, "target.type: "+err.Error())
+	}
+	if err := validateAddr(route.Target.Addr); err != nil {
+		errs = append(errs, "target.addr: "+err.Error())
+	}
+	if s := route.Source; s != nil {
+		if s.ID != "" && !containerIDRegex.MatchString(s.ID) {
+			errs = append(errs, fmt.Sprintf("source.id: %q is not a container ID", s.ID))
+		}
+		set := 0
+		for _, v := range []string{s.ID, s.Name, s.Filter} {
+			if v != "" {
+				set++
+			}
+		}
+		if set > 1 {
+			errs = append(errs, "source: set only one of id, name and filter")
+		}
+		for _, typ := range s.Types {
+			if typ != "stdout" && typ != "stderr" {
+				errs = append(errs, fmt.Sprintf("source.types: unknown type %q, use stdout or stderr", typ))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateAddr checks that addr is a host and port.
+func validateAddr(addr string) error {
+	if addr == "" {
+		return fmt.Errorf("missing, use host:port")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return fmt.Errorf("%q has no host", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%q has an invalid port", addr)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRoute(t *testing.T) {
+	valid := []*Route{
+		{Target: Target{Type: "syslog", Addr: "logs.papertrailapp.com:55555"}},
+		{Source: &Source{ID: "abcdef123456", Types: []string{"stderr"}},
+			Target: Target{Type: "syslog+tls", Addr: "10.0.0.1:6514"}},
+		{Source: &Source{Filter: "_db"}, Target: Target{Type: "syslog+tcp", Addr: "[::1]:514"}},
+	}
+	for _, route := range valid {
+		if err := validateRoute(route); err != nil {
+			t.Errorf("expected %+v to be valid; got %v", route, err)
+		}
+	}
+
+	invalid := map[string]*Route{
+		"target.type":  {Target: Target{Type: "http", Addr: "localhost:514"}},
+		"target.addr":  {Target: Target{Type: "syslog", Addr: "localhost"}},
+		"source.id":    {Source: &Source{ID: "not-an-id"}, Target: Target{Type: "syslog", Addr: "localhost:514"}},
+		"source:":      {Source: &Source{Name: "a", Filter: "b"}, Target: Target{Type: "syslog", Addr: "localhost:514"}},
+		"source.types": {Source: &Source{Types: []string{"stdin"}}, Target: Target{Type: "syslog", Addr: "localhost:514"}},
+	}
+	for field, route := range invalid {
+		if err := validateRoute(route); err == nil || !strings.HasPrefix(err.Error(), field) {
+			t.Errorf("expected an error for %s; got %v", field, err)
+		}
+	}
+
+	err := validateRoute(&Route{Target: Target{Addr: "localhost:0"}})
+	if errs, ok := err.(validationError); !ok || len(errs) != 2 {
+		t.Errorf("expected every problem to be reported; got %v", err)
+	}
+}